@@ -36,7 +36,7 @@ var Filters = map[string]Desc{
 	"regex":               {"Extracts regex capture group. Pattern must have at least one capture group.", InOut{"string", "string"}},
 	"replace":             {"Replaces all occurrences of old string with new string.", InOut{"string", "string"}},
 	"replaceRegex":        {"Replaces all occurrences of a pattern with new string.", InOut{"string", "string"}},
-	"split":               {"Splits to a list of strings around occurrences of the specified delimiter.", InOut{"string", "string"}},
+	"split":               {"Splits to a list of strings around occurrences of the specified delimiter.", InOut{"string", "collection"}},
 	"toDate":              {"Converts a string to a date given a specification format.", InOut{"string", "date"}},
 	"toFloat":             {"Converts value to float number.", InOut{"string|number", "number"}},
 	"toHex":               {"Converts bytes to hexadecimal string.", InOut{"bytes", "string"}},
@@ -47,3 +47,139 @@ var Filters = map[string]Desc{
 	"urlQueryParam":       {"Returns the value of a query parameter in a URL.", InOut{"string", "string"}},
 	"xpath":               {"Evaluates a XPath expression.", InOut{"string", "string"}},
 }
+
+// FilterArgs are the names of the arguments of the filters that take any
+var FilterArgs = map[string][]string{
+	"decode":        {"encoding"},
+	"format":        {"format"},
+	"jsonpath":      {"expression"},
+	"nth":           {"index"},
+	"regex":         {"pattern"},
+	"replace":       {"old", "new"},
+	"replaceRegex":  {"pattern", "new"},
+	"split":         {"delimiter"},
+	"toDate":        {"format"},
+	"urlQueryParam": {"name"},
+	"xpath":         {"expression"},
+}
+
+var Sections = map[string]Desc{
+	"Asserts":           {"Asserts on the response. Each line is a query, optional filters and a predicate e.g. `jsonpath \"$.id\" == 1`.", InOut{}},
+	"Captures":          {"Captures values from the response into variables that can be used in the following entries e.g. `id: jsonpath \"$.id\"`.", InOut{}},
+	"Options":           {"Options that only apply to this entry e.g. `insecure: true` or `retry: 3`.", InOut{}},
+	"QueryStringParams": {"Query string parameters added to the URL e.g. `status: available`. Alias `[Query]`.", InOut{}},
+	"Query":             {"Query string parameters added to the URL e.g. `status: available`. Alias of `[QueryStringParams]`.", InOut{}},
+	"FormParams":        {"Form parameters sent as an application/x-www-form-urlencoded body e.g. `name: rex`. Alias `[Form]`.", InOut{}},
+	"Form":              {"Form parameters sent as an application/x-www-form-urlencoded body e.g. `name: rex`. Alias of `[FormParams]`.", InOut{}},
+	"MultipartFormData": {"Multipart form data sent as a multipart/form-data body. Files are sent with `file,path;` e.g. `upload: file,data.txt;`. Alias `[Multipart]`.", InOut{}},
+	"Multipart":         {"Multipart form data sent as a multipart/form-data body. Files are sent with `file,path;` e.g. `upload: file,data.txt;`. Alias of `[MultipartFormData]`.", InOut{}},
+	"Cookies":           {"Cookies sent with the request e.g. `theme: dark`.", InOut{}},
+	"BasicAuth":         {"Basic authentication credentials sent in the Authorization header e.g. `bob: secret`.", InOut{}},
+}
+
+var Queries = map[string]Desc{
+	"status":      {"The HTTP status code of the response.", InOut{"", "number"}},
+	"version":     {"The HTTP version of the response e.g. 1.1 or 2.", InOut{"", "string"}},
+	"url":         {"The final URL of the response, after any redirections.", InOut{"", "string"}},
+	"ip":          {"The IP address of the server.", InOut{"", "string"}},
+	"header":      {"The value of a response header e.g. `header \"Content-Type\"`.", InOut{"name", "string"}},
+	"cookie":      {"A response cookie or one of its attributes e.g. `cookie \"session[Domain]\"`.", InOut{"name", "string"}},
+	"body":        {"The response body decoded as text.", InOut{"", "string"}},
+	"bytes":       {"The raw bytes of the response body.", InOut{"", "bytes"}},
+	"xpath":       {"Evaluates a XPath expression against the response body e.g. `xpath \"string(//h1)\"`.", InOut{"expression", "any"}},
+	"jsonpath":    {"Evaluates a JSONPath expression against the response body e.g. `jsonpath \"$.id\"`.", InOut{"expression", "any"}},
+	"regex":       {"Extracts the first capture group of a regex from the response body e.g. `regex \"id=(\\\\d+)\"`.", InOut{"pattern", "string"}},
+	"sha256":      {"The SHA-256 hash of the response body.", InOut{"", "bytes"}},
+	"md5":         {"The MD5 hash of the response body.", InOut{"", "bytes"}},
+	"variable":    {"The value of a variable e.g. `variable \"id\"`.", InOut{"name", "any"}},
+	"duration":    {"The time taken by the request in milliseconds.", InOut{"", "number"}},
+	"certificate": {"An attribute of the server certificate: Subject, Issuer, Start-Date, Expire-Date or Serial-Number.", InOut{"attribute", "string|date"}},
+	"redirects":   {"The redirections followed before the final response.", InOut{"", "collection"}},
+}
+
+var Predicates = map[string]Desc{
+	"==":           {"Checks that the value is equal to the expected value.", InOut{"any", "bool"}},
+	"!=":           {"Checks that the value is not equal to the expected value.", InOut{"any", "bool"}},
+	">":            {"Checks that the value is greater than the expected value.", InOut{"number|string|date", "bool"}},
+	">=":           {"Checks that the value is greater than or equal to the expected value.", InOut{"number|string|date", "bool"}},
+	"<":            {"Checks that the value is less than the expected value.", InOut{"number|string|date", "bool"}},
+	"<=":           {"Checks that the value is less than or equal to the expected value.", InOut{"number|string|date", "bool"}},
+	"startsWith":   {"Checks that the value starts with the expected prefix.", InOut{"string|bytes", "bool"}},
+	"endsWith":     {"Checks that the value ends with the expected suffix.", InOut{"string|bytes", "bool"}},
+	"contains":     {"Checks that the value contains the expected substring.", InOut{"string|bytes", "bool"}},
+	"includes":     {"Checks that the collection includes the expected item.", InOut{"collection", "bool"}},
+	"matches":      {"Checks that the value matches the expected regex.", InOut{"string", "bool"}},
+	"exists":       {"Checks that the query returned a value.", InOut{"any", "bool"}},
+	"isBoolean":    {"Checks that the value is a boolean.", InOut{"any", "bool"}},
+	"isCollection": {"Checks that the value is a collection.", InOut{"any", "bool"}},
+	"isDate":       {"Checks that the value is a date.", InOut{"any", "bool"}},
+	"isEmpty":      {"Checks that the collection or string is empty.", InOut{"collection|string", "bool"}},
+	"isFloat":      {"Checks that the value is a float.", InOut{"any", "bool"}},
+	"isInteger":    {"Checks that the value is an integer.", InOut{"any", "bool"}},
+	"isIpv4":       {"Checks that the value is an IPv4 address.", InOut{"string", "bool"}},
+	"isIpv6":       {"Checks that the value is an IPv6 address.", InOut{"string", "bool"}},
+	"isIsoDate":    {"Checks that the value is a string formatted as an ISO 8601 date.", InOut{"string", "bool"}},
+	"isList":       {"Checks that the value is a list.", InOut{"any", "bool"}},
+	"isNumber":     {"Checks that the value is a number.", InOut{"any", "bool"}},
+	"isObject":     {"Checks that the value is an object.", InOut{"any", "bool"}},
+	"isString":     {"Checks that the value is a string.", InOut{"any", "bool"}},
+	"isUuid":       {"Checks that the value is a UUID.", InOut{"string", "bool"}},
+	"not":          {"Negates the predicate that follows it e.g. `not contains \"error\"`.", InOut{"predicate", "bool"}},
+}
+
+type OptionType string
+
+const (
+	OptionBool     OptionType = "boolean"
+	OptionInteger  OptionType = "integer"
+	OptionDuration OptionType = "duration"
+	OptionString   OptionType = "string"
+	// OptionVariable is a name=value pair
+	OptionVariable OptionType = "variable"
+)
+
+type OptionDesc struct {
+	Type        OptionType
+	Description string
+}
+
+// Options are the options of an [Options] section
+var Options = map[string]OptionDesc{
+	"aws-sigv4":        {OptionString, "Generates an AWS V4 signature header e.g. `aws:amz:eu-central-1:sts`."},
+	"cacert":           {OptionString, "The CA certificate file used to verify the peer."},
+	"cert":             {OptionString, "The client certificate file, with an optional password e.g. `client.pem:password`."},
+	"key":              {OptionString, "The private key file of the client certificate."},
+	"compressed":       {OptionBool, "Requests a compressed response and decompresses it."},
+	"connect-timeout":  {OptionDuration, "The maximum time allowed to connect to the server."},
+	"connect-to":       {OptionString, "Connects to another host and port instead e.g. `example.com:443:localhost:8443`."},
+	"delay":            {OptionDuration, "Waits before sending the request."},
+	"http1.0":          {OptionBool, "Uses HTTP/1.0."},
+	"http1.1":          {OptionBool, "Uses HTTP/1.1."},
+	"http2":            {OptionBool, "Uses HTTP/2."},
+	"http3":            {OptionBool, "Uses HTTP/3."},
+	"insecure":         {OptionBool, "Allows insecure SSL connections, the certificate isn't verified."},
+	"ipv4":             {OptionBool, "Resolves host names to IPv4 addresses only."},
+	"ipv6":             {OptionBool, "Resolves host names to IPv6 addresses only."},
+	"limit-rate":       {OptionInteger, "The maximum transfer rate in bytes per second."},
+	"location":         {OptionBool, "Follows redirections."},
+	"location-trusted": {OptionBool, "Follows redirections and sends the credentials to other hosts."},
+	"max-redirs":       {OptionInteger, "The maximum number of redirections to follow, -1 for no limit."},
+	"max-time":         {OptionDuration, "The maximum time allowed for the transfer."},
+	"netrc":            {OptionBool, "Reads the credentials from ~/.netrc."},
+	"netrc-file":       {OptionString, "Reads the credentials from this netrc file."},
+	"netrc-optional":   {OptionBool, "Reads the credentials from ~/.netrc if it exists."},
+	"output":           {OptionString, "Writes the response body to this file, `-` for stdout."},
+	"path-as-is":       {OptionBool, "Sends the path as it is, without squashing /../ or /./ sequences."},
+	"pinnedpubkey":     {OptionString, "The public key the server certificate must match."},
+	"proxy":            {OptionString, "Uses this proxy e.g. `localhost:3128`."},
+	"repeat":           {OptionInteger, "Repeats the entry this many times, -1 for ever."},
+	"resolve":          {OptionString, "Resolves a host and port to an address e.g. `example.com:443:127.0.0.1`."},
+	"retry":            {OptionInteger, "The maximum number of retries when an assert fails, -1 for no limit."},
+	"retry-interval":   {OptionDuration, "The time to wait between retries."},
+	"skip":             {OptionBool, "Skips the entry."},
+	"unix-socket":      {OptionString, "Connects through this Unix domain socket."},
+	"user":             {OptionString, "Basic authentication credentials e.g. `bob:secret`."},
+	"variable":         {OptionVariable, "Defines a variable that can be used in this entry e.g. `id=1`."},
+	"verbose":          {OptionBool, "Logs the details of the entry."},
+	"very-verbose":     {OptionBool, "Logs the details of the entry, including the response body."},
+}
@@ -0,0 +1,80 @@
+package codeactions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethancarlsson/hurl-lsp/diagnostics"
+	"github.com/ethancarlsson/hurl-lsp/document"
+	"github.com/ethancarlsson/hurl-lsp/hurlfile"
+	"github.com/ethancarlsson/hurl-lsp/openapi"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// maxPaths is the most paths suggested for a path that isn't in the spec
+const maxPaths = 3
+
+// Operations returns quick fixes for the request lines in rng whose path or
+// method isn't in their openapi spec, hf is parsed from lines. They replace
+// the path with the closest documented paths, keeping the request's values of
+// their parameters, or the method with the documented methods.
+func Operations(hf *hurlfile.HurlFile, lines []string, specs openapi.Finder, uri protocol.DocumentUri, rng protocol.Range) []protocol.CodeAction {
+	actions := make([]protocol.CodeAction, 0)
+	for _, entry := range hf.Entries {
+		req := entry.Request
+		line := protocol.UInteger(req.Method.Range.StartLine)
+		if line < rng.Start.Line || line > rng.End.Line {
+			continue
+		}
+
+		oai := specs.Find(req.Target.Target)
+		if len(oai.Paths) == 0 {
+			continue
+		}
+
+		diag, ok := diagnostics.Operation(req, lines, oai)
+		if !ok {
+			continue
+		}
+
+		fix := func(title string, r hurlfile.SourceRange, text string) protocol.CodeAction {
+			kind := protocol.CodeActionKindQuickFix
+			return protocol.CodeAction{
+				Title:       title,
+				Kind:        &kind,
+				Diagnostics: []protocol.Diagnostic{diag},
+				Edit: &protocol.WorkspaceEdit{
+					Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+						uri: {{Range: document.Range(lines, r), NewText: text}},
+					},
+				},
+			}
+		}
+
+		switch diag.Code.Value {
+		case diagnostics.CodeUnknownPath:
+			base, path, query := oai.TargetPath(req.Target.Target)
+			suggested := 0
+			for _, closest := range oai.ClosestPaths(req.Target.Target, len(oai.Paths)) {
+				filled, ok := openapi.FillPath(path, closest)
+				if !ok {
+					// the request has no value for one of the parameters
+					continue
+				}
+
+				actions = append(actions, fix(fmt.Sprintf("Change path to %s", filled), req.Target.Range, base+filled+query))
+				if suggested++; suggested == maxPaths {
+					break
+				}
+			}
+		case diagnostics.CodeMethodNotAllowed:
+			pathInSpec, _ := oai.Match(req.Target.Target)
+			for _, method := range oai.ClosestMethods(req.Method.Name, pathInSpec) {
+				method = strings.ToUpper(method)
+				actions = append(actions, fix(fmt.Sprintf("Change method to %s", method), req.Method.Range, method))
+			}
+		}
+	}
+
+	return actions
+}
@@ -0,0 +1,91 @@
+package codeactions_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ethancarlsson/hurl-lsp/codeactions"
+	"github.com/ethancarlsson/hurl-lsp/expect"
+	"github.com/ethancarlsson/hurl-lsp/hurlfile"
+	"github.com/ethancarlsson/hurl-lsp/openapi"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestOperations(t *testing.T) {
+	contents, err := os.ReadFile("../fixtures/petstore.yaml")
+	expect.NoErr(t, err)
+	oai, err := openapi.Parse("yaml", contents)
+	expect.NoErr(t, err)
+
+	lines := []string{
+		"GET {{url}}/stor/inventry?all=true",
+		"",
+		"DELETE {{url}}/pet",
+		"",
+		"POST {{url}}/pet/42/uploadImag",
+	}
+	hf, err := hurlfile.Parse(lines)
+	expect.NoErr(t, err)
+
+	type fix struct {
+		title, text string
+		line        protocol.UInteger
+		start, end  protocol.UInteger
+	}
+
+	tests := []struct {
+		name     string
+		from, to protocol.UInteger
+		fixes    []fix
+	}{
+		{
+			name: "closest paths",
+			from: 0, to: 0,
+			fixes: []fix{
+				{title: "Change path to /store/inventory", text: "{{url}}/store/inventory?all=true", line: 0, start: 4, end: 34},
+				{title: "Change path to /store/order", text: "{{url}}/store/order?all=true", line: 0, start: 4, end: 34},
+				{title: "Change path to /user/login", text: "{{url}}/user/login?all=true", line: 0, start: 4, end: 34},
+			},
+		},
+		{
+			name: "documented methods",
+			from: 1, to: 2,
+			fixes: []fix{
+				{title: "Change method to PUT", text: "PUT", line: 2, start: 0, end: 6},
+				{title: "Change method to POST", text: "POST", line: 2, start: 0, end: 6},
+			},
+		},
+		{
+			name: "parameters keep their values",
+			from: 4, to: 4,
+			fixes: []fix{
+				{title: "Change path to /pet/42/uploadImage", text: "{{url}}/pet/42/uploadImage", line: 4, start: 5, end: 30},
+				{title: "Change path to /pet/42", text: "{{url}}/pet/42", line: 4, start: 5, end: 30},
+				{title: "Change path to /pet/findByTags", text: "{{url}}/pet/findByTags", line: 4, start: 5, end: 30},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actions := codeactions.Operations(hf, lines, oai, "test.hurl", protocol.Range{
+				Start: protocol.Position{Line: tt.from},
+				End:   protocol.Position{Line: tt.to},
+			})
+
+			expect.Equals(t, len(tt.fixes), len(actions))
+			for i, f := range tt.fixes {
+				expect.Equals(t, f.title, actions[i].Title)
+				expect.Equals(t, protocol.CodeActionKindQuickFix, *actions[i].Kind)
+				expect.Equals(t, 1, len(actions[i].Diagnostics))
+				expect.Equals(t, []protocol.TextEdit{{
+					Range: protocol.Range{
+						Start: protocol.Position{Line: f.line, Character: f.start},
+						End:   protocol.Position{Line: f.line, Character: f.end},
+					},
+					NewText: f.text,
+				}}, actions[i].Edit.Changes["test.hurl"])
+			}
+		})
+	}
+}
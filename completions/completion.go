@@ -70,6 +70,80 @@ func AddRespSection(items []protocol.CompletionItem) []protocol.CompletionItem {
 	return items
 }
 
+// reqSectionSnippets are the request sections with a snippet for their first line
+var reqSectionSnippets = []struct{ name, snippet string }{
+	{"QueryStringParams", "${1:name}: ${2:value}"},
+	{"Query", "${1:name}: ${2:value}"},
+	{"FormParams", "${1:name}: ${2:value}"},
+	{"Form", "${1:name}: ${2:value}"},
+	{"MultipartFormData", "${1:field}: file,${2:path};"},
+	{"Multipart", "${1:field}: file,${2:path};"},
+	{"Cookies", "${1:name}: ${2:value}"},
+	{"BasicAuth", "${1:user}: ${2:password}"},
+	{"Options", "${1:option}: ${2:value}"},
+}
+
+func AddReqSection(items []protocol.CompletionItem) []protocol.CompletionItem {
+	kind := protocol.CompletionItemKindEnumMember
+	format := protocol.InsertTextFormatSnippet
+
+	for _, section := range reqSectionSnippets {
+		insertText := "[" + section.name + "]\n" + section.snippet
+		items = append(items, protocol.CompletionItem{
+			Label:            section.name,
+			Kind:             &kind,
+			InsertText:       &insertText,
+			InsertTextFormat: &format,
+			Documentation:    ptr(builtin.Sections[section.name].Desctiption),
+		})
+	}
+
+	return items
+}
+
+func AddOptions(items []protocol.CompletionItem) []protocol.CompletionItem {
+	kind := protocol.CompletionItemKindProperty
+
+	for name, desc := range builtin.Options {
+		insertText := name + ": "
+		items = append(items, protocol.CompletionItem{
+			Label:         name,
+			Kind:          &kind,
+			InsertText:    &insertText,
+			Documentation: &desc.Description,
+			Detail:        ptr(string(desc.Type)),
+		})
+	}
+
+	return items
+}
+
+// optionValues are typical values of each option type
+var optionValues = map[builtin.OptionType][]string{
+	builtin.OptionBool:     {"true", "false"},
+	builtin.OptionInteger:  {"1", "3", "-1"},
+	builtin.OptionDuration: {"500ms", "1s", "1m"},
+}
+
+func AddOptionValues(items []protocol.CompletionItem, option string) []protocol.CompletionItem {
+	kind := protocol.CompletionItemKindValue
+	desc, ok := builtin.Options[option]
+	if !ok {
+		return items
+	}
+
+	for _, value := range optionValues[desc.Type] {
+		items = append(items, protocol.CompletionItem{
+			Label:      value,
+			Kind:       &kind,
+			InsertText: &value,
+			Detail:     ptr(string(desc.Type)),
+		})
+	}
+
+	return items
+}
+
 func AddVars(items []protocol.CompletionItem, vars []string) []protocol.CompletionItem {
 	kind := protocol.CompletionItemKindVariable
 
@@ -0,0 +1,53 @@
+package diagnostics
+
+import (
+	"fmt"
+
+	"github.com/ethancarlsson/hurl-lsp/document"
+	"github.com/ethancarlsson/hurl-lsp/hurlfile"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+const source = "hurl_ls"
+
+// Parse returns the problems the parser found in the hurl file
+func Parse(hf *hurlfile.HurlFile, lines []string) []protocol.Diagnostic {
+	diags := make([]protocol.Diagnostic, 0, len(hf.Diagnostics))
+	for _, d := range hf.Diagnostics {
+		diags = append(diags, FromHurl(d, lines))
+	}
+
+	return diags
+}
+
+// UndefinedVariables warns about every {{variable}} that isn't captured before
+// it is used or in the external variables.
+func UndefinedVariables(hf *hurlfile.HurlFile, lines []string, external []string) []protocol.Diagnostic {
+	undefined := hf.UndefinedTemplates(external)
+	diags := make([]protocol.Diagnostic, 0, len(undefined))
+	for _, tmpl := range undefined {
+		diags = append(diags, FromHurl(hurlfile.Diagnostic{
+			Range:    tmpl.Range,
+			Severity: hurlfile.SeverityWarning,
+			Message:  fmt.Sprintf("undefined variable %q, it isn't captured before this line or declared in the variables config", tmpl.Value),
+		}, lines))
+	}
+
+	return diags
+}
+
+// FromHurl converts a diagnostic of the hurl file parsed from the lines
+func FromHurl(d hurlfile.Diagnostic, lines []string) protocol.Diagnostic {
+	severity := protocol.DiagnosticSeverity(d.Severity)
+
+	return protocol.Diagnostic{
+		Range:    document.Range(lines, d.Range),
+		Severity: &severity,
+		Source:   ptr(source),
+		Message:  d.Message,
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
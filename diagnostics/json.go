@@ -0,0 +1,116 @@
+package diagnostics
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethancarlsson/hurl-lsp/hurlfile"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// JSONBodies reports the first syntax error of every JSON request and
+// response body, including ```json multiline strings. A {{template}} is valid
+// anywhere a value or a part of a string is.
+func JSONBodies(hf *hurlfile.HurlFile, lines []string) []protocol.Diagnostic {
+	diags := make([]protocol.Diagnostic, 0)
+	for _, entry := range hf.Entries {
+		bodies := []*hurlfile.Body{entry.Request.TypedBody}
+		if entry.Response != nil {
+			bodies = append(bodies, entry.Response.TypedBody)
+		}
+
+		for _, body := range bodies {
+			if body == nil || !isJSON(body) || strings.TrimSpace(body.Content.Value) == "" {
+				continue
+			}
+
+			if d, ok := checkJSON(body.Content); !ok {
+				diags = append(diags, FromHurl(d, lines))
+			}
+		}
+	}
+
+	return diags
+}
+
+func isJSON(body *hurlfile.Body) bool {
+	return body.Kind == hurlfile.BodyJSON || (body.Kind == hurlfile.BodyMultiline && body.Lang.Value == "json")
+}
+
+// checkJSON returns a diagnostic on the character where the JSON stops being valid
+func checkJSON(content hurlfile.Ranged[string]) (hurlfile.Diagnostic, bool) {
+	var v any
+	err := json.Unmarshal(withoutTemplates(content.Value), &v)
+	if err == nil {
+		return hurlfile.Diagnostic{}, true
+	}
+
+	// the syntax error is found after reading the offending character
+	offset := len(content.Value) - 1
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		offset = min(max(int(syntaxErr.Offset)-1, 0), offset)
+	}
+
+	return hurlfile.Diagnostic{
+		Range:    contentRange(content, offset, offset+1),
+		Severity: hurlfile.SeverityError,
+		Message:  fmt.Sprintf("invalid JSON body, %s", err),
+	}, false
+}
+
+// contentRange converts offsets in the content of a body to a range in the file
+func contentRange(content hurlfile.Ranged[string], start, end int) hurlfile.SourceRange {
+	position := func(offset int) (int, int) {
+		line := strings.Count(content.Value[:offset], "\n")
+		col := offset - (strings.LastIndex(content.Value[:offset], "\n") + 1)
+		if line == 0 {
+			col += content.Range.StartCol
+		}
+
+		return line + content.Range.StartLine, col
+	}
+
+	startLine, startCol := position(start)
+	endLine, endCol := position(end)
+
+	return hurlfile.SourceRange{StartLine: startLine, StartCol: startCol, EndLine: endLine, EndCol: endCol}
+}
+
+// withoutTemplates replaces the templates with JSON of the same length, so
+// the offset of a syntax error is the same as in the original text. Templates
+// in strings become letters and other templates become a number padded with
+// spaces.
+func withoutTemplates(text string) []byte {
+	b := []byte(text)
+	inString := false
+	for i := 0; i < len(b); i++ {
+		switch {
+		case inString && b[i] == '\\':
+			i++
+		case b[i] == '"':
+			inString = !inString
+		case bytes.HasPrefix(b[i:], []byte("{{")):
+			end := bytes.Index(b[i:], []byte("}}"))
+			if end < 0 {
+				continue
+			}
+
+			fill, first := byte('x'), byte('x')
+			if !inString {
+				fill, first = ' ', '0'
+			}
+
+			b[i] = first
+			for j := i + 1; j < i+end+2; j++ {
+				b[j] = fill
+			}
+			i += end + 1
+		}
+	}
+
+	return b
+}
@@ -0,0 +1,89 @@
+package diagnostics_test
+
+import (
+	"testing"
+
+	"github.com/ethancarlsson/hurl-lsp/diagnostics"
+	"github.com/ethancarlsson/hurl-lsp/expect"
+	"github.com/ethancarlsson/hurl-lsp/hurlfile"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestJSONBodies(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		message string
+		// line and character of the error, ignored when message is empty
+		line, char protocol.UInteger
+	}{
+		{
+			name:  "valid with templates",
+			lines: []string{"POST /", `{"id": {{id}}, "name": "{{first}} {{last}}", "tags": [{{tag}}]}`},
+		},
+		{
+			name:  "empty object over lines",
+			lines: []string{"PUT /", "{", "", "}"},
+		},
+		{
+			name:    "trailing comma",
+			lines:   []string{"POST /", "{", `  "a": 1,`, "}"},
+			message: "invalid JSON body, invalid character '}' looking for beginning of object key string",
+			line:    3, char: 0,
+		},
+		{
+			name:    "missing quotes",
+			lines:   []string{"POST /", `  {"a": 1, b: 2}`},
+			message: "invalid JSON body, invalid character 'b' looking for beginning of object key string",
+			line:    1, char: 11,
+		},
+		{
+			name:    "characters are utf-16 code units",
+			lines:   []string{"POST /", `{"é😀": 1, b: 2}`},
+			message: "invalid JSON body, invalid character 'b' looking for beginning of object key string",
+			line:    1, char: 11,
+		},
+		{
+			name:    "unbalanced braces",
+			lines:   []string{"POST /", "{", `  "a": [1, 2`, "}"},
+			message: "invalid JSON body, invalid character '}' after array element",
+			line:    3, char: 0,
+		},
+		{
+			name:    "unexpected end",
+			lines:   []string{"POST /", `{"a": {{a}}`},
+			message: "invalid JSON body, unexpected end of JSON input",
+			line:    1, char: 10,
+		},
+		{
+			name:    "response and multiline json",
+			lines:   []string{"GET /", "HTTP 200", "```json", `{"a" 1}`, "```"},
+			message: "invalid JSON body, invalid character '1' after object key",
+			line:    3, char: 5,
+		},
+		{
+			name:  "other bodies aren't json",
+			lines: []string{"POST /", "```graphql", "{ pets }", "```"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hf, err := hurlfile.Parse(tt.lines)
+			expect.NoErr(t, err)
+
+			diags := diagnostics.JSONBodies(hf, tt.lines)
+			if tt.message == "" {
+				expect.Equals(t, 0, len(diags))
+				return
+			}
+
+			expect.Equals(t, 1, len(diags))
+			expect.Equals(t, tt.message, diags[0].Message)
+			expect.Equals(t, protocol.Range{
+				Start: protocol.Position{Line: tt.line, Character: tt.char},
+				End:   protocol.Position{Line: tt.line, Character: tt.char + 1},
+			}, diags[0].Range)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+type jsonKind int
+
+const (
+	jsonObject jsonKind = iota
+	jsonArray
+	jsonString
+	jsonNumber
+	jsonBool
+	jsonNull
+	// jsonTemplate is a {{template}} outside of a string, its type is only
+	// known when hurl runs
+	jsonTemplate
+)
+
+// jsonNode is a JSON value with the offsets of its text in the body
+type jsonNode struct {
+	kind       jsonKind
+	start, end int
+	members    []jsonMember
+	items      []*jsonNode
+}
+
+type jsonMember struct {
+	key              string
+	keyStart, keyEnd int
+	// templated is true when the key contains a template
+	templated bool
+	value     *jsonNode
+}
+
+// parseJSONTree parses a body that json.Valid accepts once its templates are
+// replaced by withoutTemplates. It returns nil for any other text.
+func parseJSONTree(text string) *jsonNode {
+	p := jsonTreeParser{text: text, src: withoutTemplates(text)}
+	node := p.value()
+	p.space()
+	if node == nil || p.i != len(p.src) {
+		return nil
+	}
+
+	return node
+}
+
+type jsonTreeParser struct {
+	// text is the original body and src is the body without templates
+	text string
+	src  []byte
+	i    int
+}
+
+func (p *jsonTreeParser) space() {
+	for p.i < len(p.src) && strings.IndexByte(" \t\r\n", p.src[p.i]) >= 0 {
+		p.i++
+	}
+}
+
+func (p *jsonTreeParser) value() *jsonNode {
+	p.space()
+	if p.i >= len(p.src) {
+		return nil
+	}
+
+	start := p.i
+	switch c := p.src[p.i]; {
+	case strings.HasPrefix(p.text[p.i:], "{{"):
+		p.i += strings.Index(p.text[p.i:], "}}") + 2
+		return &jsonNode{kind: jsonTemplate, start: start, end: p.i}
+	case c == '{':
+		return p.object()
+	case c == '[':
+		return p.array()
+	case c == '"':
+		if !p.string() {
+			return nil
+		}
+
+		return &jsonNode{kind: jsonString, start: start, end: p.i}
+	default:
+		for p.i < len(p.src) && strings.IndexByte(",]} \t\r\n", p.src[p.i]) < 0 {
+			p.i++
+		}
+
+		node := &jsonNode{kind: jsonNumber, start: start, end: p.i}
+		switch string(p.src[start:p.i]) {
+		case "true", "false":
+			node.kind = jsonBool
+		case "null":
+			node.kind = jsonNull
+		}
+
+		return node
+	}
+}
+
+func (p *jsonTreeParser) object() *jsonNode {
+	node := &jsonNode{kind: jsonObject, start: p.i, members: make([]jsonMember, 0)}
+	p.i++
+	for {
+		p.space()
+		if p.i >= len(p.src) {
+			return nil
+		}
+
+		switch p.src[p.i] {
+		case '}':
+			p.i++
+			node.end = p.i
+			return node
+		case ',':
+			p.i++
+			continue
+		}
+
+		member := jsonMember{keyStart: p.i}
+		if !p.string() {
+			return nil
+		}
+		member.keyEnd = p.i
+		raw := p.text[member.keyStart:member.keyEnd]
+		member.templated = strings.Contains(raw, "{{")
+		if err := json.Unmarshal([]byte(raw), &member.key); err != nil {
+			member.key = strings.Trim(raw, `"`)
+		}
+
+		p.space()
+		if p.i >= len(p.src) || p.src[p.i] != ':' {
+			return nil
+		}
+		p.i++
+
+		if member.value = p.value(); member.value == nil {
+			return nil
+		}
+		node.members = append(node.members, member)
+	}
+}
+
+func (p *jsonTreeParser) array() *jsonNode {
+	node := &jsonNode{kind: jsonArray, start: p.i, items: make([]*jsonNode, 0)}
+	p.i++
+	for {
+		p.space()
+		if p.i >= len(p.src) {
+			return nil
+		}
+
+		switch p.src[p.i] {
+		case ']':
+			p.i++
+			node.end = p.i
+			return node
+		case ',':
+			p.i++
+			continue
+		}
+
+		item := p.value()
+		if item == nil {
+			return nil
+		}
+		node.items = append(node.items, item)
+	}
+}
+
+// string consumes a quoted string and reports whether it was terminated
+func (p *jsonTreeParser) string() bool {
+	if p.i >= len(p.src) || p.src[p.i] != '"' {
+		return false
+	}
+
+	for p.i++; p.i < len(p.src); p.i++ {
+		switch p.src[p.i] {
+		case '\\':
+			p.i++
+		case '"':
+			p.i++
+			return true
+		}
+	}
+
+	return false
+}
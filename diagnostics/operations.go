@@ -0,0 +1,76 @@
+package diagnostics
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethancarlsson/hurl-lsp/hurlfile"
+	"github.com/ethancarlsson/hurl-lsp/openapi"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// The codes of the diagnostics that have quick fixes
+const (
+	CodeUnknownPath      = "unknown-path"
+	CodeMethodNotAllowed = "method-not-allowed"
+)
+
+// Operations warns about request lines whose path isn't in their openapi spec
+// or whose method isn't documented for the path. Requests without a spec
+// aren't reported.
+func Operations(hf *hurlfile.HurlFile, lines []string, specs openapi.Finder) []protocol.Diagnostic {
+	diags := make([]protocol.Diagnostic, 0)
+	for _, entry := range hf.Entries {
+		oai := specs.Find(entry.Request.Target.Target)
+		if len(oai.Paths) == 0 {
+			continue
+		}
+
+		if d, ok := Operation(entry.Request, lines, oai); ok {
+			diags = append(diags, d)
+		}
+	}
+
+	return diags
+}
+
+// Operation returns the warning of a single request line of the lines, ok is
+// false when the spec documents the request
+func Operation(req hurlfile.Request, lines []string, oai openapi.OAI) (protocol.Diagnostic, bool) {
+	target := req.Target.Target
+	if _, path, _ := oai.TargetPath(target); path == "" {
+		// only the base url is known, e.g. GET {{url}}
+		return protocol.Diagnostic{}, false
+	}
+
+	pathInSpec, ok := oai.Match(target)
+	if !ok {
+		d := FromHurl(hurlfile.Diagnostic{
+			Range:    req.Target.Range,
+			Severity: hurlfile.SeverityWarning,
+			Message:  fmt.Sprintf("%s isn't a path in the openapi spec", target),
+		}, lines)
+		d.Code = &protocol.IntegerOrString{Value: CodeUnknownPath}
+
+		return d, true
+	}
+
+	methods := oai.Methods(pathInSpec)
+	for _, m := range methods {
+		if strings.EqualFold(m, req.Method.Name) {
+			return protocol.Diagnostic{}, false
+		}
+	}
+
+	d := FromHurl(hurlfile.Diagnostic{
+		Range:    req.Method.Range,
+		Severity: hurlfile.SeverityWarning,
+		Message: fmt.Sprintf(
+			"%s isn't documented for %s, the documented methods are %s",
+			strings.ToUpper(req.Method.Name), pathInSpec, strings.ToUpper(strings.Join(methods, ", ")),
+		),
+	}, lines)
+	d.Code = &protocol.IntegerOrString{Value: CodeMethodNotAllowed}
+
+	return d, true
+}
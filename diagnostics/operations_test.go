@@ -0,0 +1,53 @@
+package diagnostics_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ethancarlsson/hurl-lsp/diagnostics"
+	"github.com/ethancarlsson/hurl-lsp/expect"
+	"github.com/ethancarlsson/hurl-lsp/hurlfile"
+	"github.com/ethancarlsson/hurl-lsp/openapi"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestOperations(t *testing.T) {
+	contents, err := os.ReadFile("../fixtures/petstore.yaml")
+	expect.NoErr(t, err)
+	oai, err := openapi.Parse("yaml", contents)
+	expect.NoErr(t, err)
+
+	lines := []string{
+		"GET {{url}}/pet/findByStatus?status=sold",
+		"",
+		"GET {{url}}/stor/inventry # typo",
+		"",
+		"DELETE {{url}}/pet",
+		"",
+		"GET {{url}}",
+	}
+	hf, err := hurlfile.Parse(lines)
+	expect.NoErr(t, err)
+
+	diags := diagnostics.Operations(hf, lines, oai)
+	expect.Equals(t, 2, len(diags))
+
+	expect.Equals(t, "{{url}}/stor/inventry isn't a path in the openapi spec", diags[0].Message)
+	expect.Equals(t, diagnostics.CodeUnknownPath, diags[0].Code.Value)
+	expect.Equals(t, protocol.DiagnosticSeverityWarning, *diags[0].Severity)
+	expect.Equals(t, protocol.Range{
+		Start: protocol.Position{Line: 2, Character: 4},
+		End:   protocol.Position{Line: 2, Character: 25},
+	}, diags[0].Range)
+
+	expect.Equals(t, "DELETE isn't documented for /pet, the documented methods are PUT, POST", diags[1].Message)
+	expect.Equals(t, diagnostics.CodeMethodNotAllowed, diags[1].Code.Value)
+	expect.Equals(t, protocol.Range{
+		Start: protocol.Position{Line: 4, Character: 0},
+		End:   protocol.Position{Line: 4, Character: 6},
+	}, diags[1].Range)
+
+	t.Run("without a spec", func(t *testing.T) {
+		expect.Equals(t, 0, len(diagnostics.Operations(hf, lines, openapi.OAI{})))
+	})
+}
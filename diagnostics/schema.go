@@ -0,0 +1,211 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ethancarlsson/hurl-lsp/hurlfile"
+	"github.com/ethancarlsson/hurl-lsp/openapi"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// bodyMethods are the methods whose JSON body is checked against the spec
+var bodyMethods = map[string]bool{"POST": true, "PUT": true, "PATCH": true}
+
+// RequestBodies warns about JSON request bodies that don't match the schema of
+// the operation's requestBody in the request's openapi spec. Templates outside
+// of strings can be any type, so they are never reported.
+func RequestBodies(hf *hurlfile.HurlFile, lines []string, specs openapi.Finder) []protocol.Diagnostic {
+	diags := make([]protocol.Diagnostic, 0)
+	for _, entry := range hf.Entries {
+		req := entry.Request
+		body := req.TypedBody
+		if !bodyMethods[strings.ToUpper(req.Method.Name)] || body == nil || !isJSON(body) {
+			continue
+		}
+
+		oai := specs.Find(req.Target.Target)
+		rb := oai.GetOp(req.Method.Name, req.Target.Target).Detail.RequestBody
+		if rb == nil || rb.JSONSchema() == nil {
+			continue
+		}
+
+		if !json.Valid(withoutTemplates(body.Content.Value)) {
+			// the syntax errors are reported by JSONBodies
+			continue
+		}
+
+		root := parseJSONTree(body.Content.Value)
+		if root == nil {
+			continue
+		}
+
+		v := validator{oai: oai, content: body.Content}
+		v.check(rb.JSONSchema(), root, root.start, root.end, "$")
+		for _, d := range v.diags {
+			diags = append(diags, FromHurl(d, lines))
+		}
+	}
+
+	return diags
+}
+
+type validator struct {
+	oai     openapi.OAI
+	content hurlfile.Ranged[string]
+	diags   []hurlfile.Diagnostic
+}
+
+func (v *validator) warnf(start, end int, format string, args ...any) {
+	v.diags = append(v.diags, hurlfile.Diagnostic{
+		Range:    contentRange(v.content, start, end),
+		Severity: hurlfile.SeverityWarning,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// check validates the node against the schema, problems are reported on the
+// text between start and end, which is the key of the node when it has one.
+// path is the JSON path of the node used in the messages.
+func (v *validator) check(s *openapi.Schema, node *jsonNode, start, end int, path string) {
+	s = v.oai.Resolve(s)
+	if s == nil || node.kind == jsonTemplate {
+		return
+	}
+
+	for _, sub := range s.AllOf {
+		v.check(sub, node, start, end, path)
+	}
+
+	if s.Type != "" && !hasType(node, v.content.Value, s) {
+		v.warnf(start, end, "%s should be %s, not %s", path, s.Type, typeName(node))
+		return
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, v.content.Value[node.start:node.end]) {
+		v.warnf(start, end, "%s must be one of %s", path, enumList(s.Enum))
+		return
+	}
+
+	switch node.kind {
+	case jsonObject:
+		v.checkObject(s, node, start, end, path)
+	case jsonArray:
+		if s.Items == nil {
+			return
+		}
+
+		for i, item := range node.items {
+			v.check(s.Items, item, item.start, item.end, fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+}
+
+func (v *validator) checkObject(s *openapi.Schema, node *jsonNode, start, end int, path string) {
+	templated := false
+	present := make(map[string]bool, len(node.members))
+	for _, m := range node.members {
+		present[m.key] = true
+		if m.templated {
+			templated = true
+			continue
+		}
+
+		prop, ok := s.Properties[m.key]
+		if !ok {
+			if !s.AllowsAdditional() {
+				v.warnf(m.keyStart, m.keyEnd, "unknown property %q in %s, additional properties aren't allowed", m.key, path)
+			}
+			continue
+		}
+
+		v.check(prop, m.value, m.keyStart, m.keyEnd, path+"."+m.key)
+	}
+
+	if templated {
+		// a templated key could be any of the required properties
+		return
+	}
+
+	if node.start == start {
+		// the object has no key so the opening brace is the closest to it
+		end = start + 1
+	}
+
+	for _, name := range s.Required {
+		if !present[name] {
+			v.warnf(start, end, "missing required property %q in %s", name, path)
+		}
+	}
+}
+
+func hasType(node *jsonNode, text string, s *openapi.Schema) bool {
+	if node.kind == jsonNull {
+		return s.Nullable || s.Type == "null"
+	}
+
+	switch s.Type {
+	case "object":
+		return node.kind == jsonObject
+	case "array":
+		return node.kind == jsonArray
+	case "string":
+		return node.kind == jsonString
+	case "boolean":
+		return node.kind == jsonBool
+	case "number":
+		return node.kind == jsonNumber
+	case "integer":
+		return node.kind == jsonNumber && !strings.ContainsAny(text[node.start:node.end], ".eE")
+	}
+
+	// unknown types are left to the server
+	return true
+}
+
+func typeName(node *jsonNode) string {
+	return [...]string{
+		jsonObject:   "object",
+		jsonArray:    "array",
+		jsonString:   "string",
+		jsonNumber:   "number",
+		jsonBool:     "boolean",
+		jsonNull:     "null",
+		jsonTemplate: "template",
+	}[node.kind]
+}
+
+// inEnum reports whether the JSON text is one of the values. Strings with
+// templates could be any value, so they are always in the enum.
+func inEnum(values []any, text string) bool {
+	if strings.Contains(text, "{{") {
+		return true
+	}
+
+	var value any
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return true
+	}
+
+	// compare the encoded values so numbers like 1 and 1.0 are equal and
+	// objects can be compared at all
+	want, _ := json.Marshal(value)
+	for _, v := range values {
+		if b, _ := json.Marshal(v); string(b) == string(want) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func enumList(values []any) string {
+	list := make([]string, 0, len(values))
+	for _, v := range values {
+		b, _ := json.Marshal(v)
+		list = append(list, string(b))
+	}
+
+	return strings.Join(list, ", ")
+}
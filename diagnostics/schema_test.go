@@ -0,0 +1,166 @@
+package diagnostics_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ethancarlsson/hurl-lsp/diagnostics"
+	"github.com/ethancarlsson/hurl-lsp/expect"
+	"github.com/ethancarlsson/hurl-lsp/hurlfile"
+	"github.com/ethancarlsson/hurl-lsp/openapi"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestRequestBodies(t *testing.T) {
+	contents, err := os.ReadFile("../fixtures/petstore.yaml")
+	expect.NoErr(t, err)
+	oai, err := openapi.Parse("yaml", contents)
+	expect.NoErr(t, err)
+
+	type diag struct {
+		message    string
+		line, char protocol.UInteger
+		end        protocol.UInteger
+	}
+
+	tests := []struct {
+		name  string
+		lines []string
+		diags []diag
+	}{
+		{
+			name: "valid pet",
+			lines: []string{
+				"POST {{url}}/pet",
+				`{"id": {{id}}, "name": "{{name}}", "photoUrls": [], "status": "sold", "tags": [{"id": 1}]}`,
+			},
+		},
+		{
+			name:  "missing required properties",
+			lines: []string{"POST /pet", `{"name": "rex"}`},
+			diags: []diag{{message: `missing required property "photoUrls" in $`, line: 1, char: 0, end: 1}},
+		},
+		{
+			name: "wrong types",
+			lines: []string{
+				"PUT /pet",
+				"{",
+				`  "name": 1,`,
+				`  "photoUrls": ["a", 2],`,
+				`  "category": {"id": 1.5}`,
+				"}",
+			},
+			diags: []diag{
+				{message: "$.name should be string, not number", line: 2, char: 2, end: 8},
+				{message: "$.photoUrls[1] should be string, not number", line: 3, char: 21, end: 22},
+				{message: "$.category.id should be integer, not number", line: 4, char: 15, end: 19},
+			},
+		},
+		{
+			name:  "enum",
+			lines: []string{"POST /pet", `{"name": "rex", "photoUrls": [], "status": "lost"}`},
+			diags: []diag{{message: `$.status must be one of "available", "pending", "sold"`, line: 1, char: 33, end: 41}},
+		},
+		{
+			name:  "nested property is reported on its key",
+			lines: []string{"POST /store/order", `{"status": "placed", "complete": "yes"}`},
+			diags: []diag{{message: "$.complete should be boolean, not string", line: 1, char: 21, end: 31}},
+		},
+		{
+			name:  "methods without a body aren't checked",
+			lines: []string{"GET /pet", `{"name": 1}`},
+		},
+		{
+			name:  "invalid json is left to JSONBodies",
+			lines: []string{"POST /pet", `{"name": 1,}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hf, err := hurlfile.Parse(tt.lines)
+			expect.NoErr(t, err)
+
+			diags := diagnostics.RequestBodies(hf, tt.lines, oai)
+			expect.Equals(t, len(tt.diags), len(diags))
+			for i, d := range tt.diags {
+				expect.Equals(t, d.message, diags[i].Message)
+				expect.Equals(t, protocol.DiagnosticSeverityWarning, *diags[i].Severity)
+				expect.Equals(t, protocol.Range{
+					Start: protocol.Position{Line: d.line, Character: d.char},
+					End:   protocol.Position{Line: d.line, Character: d.end},
+				}, diags[i].Range)
+			}
+		})
+	}
+
+	t.Run("additional properties", func(t *testing.T) {
+		spec := `{
+			"paths": {"/pets": {"post": {"requestBody": {"$ref": "#/components/requestBodies/Pet"}}}},
+			"components": {
+				"requestBodies": {"Pet": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}},
+				"schemas": {"Pet": {"type": "object", "additionalProperties": false, "properties": {"name": {"type": "string"}}}}
+			}
+		}`
+		oai, err := openapi.Parse("json", []byte(spec))
+		expect.NoErr(t, err)
+		lines := []string{"POST /pets", `{"name": "rex", "age": 3}`}
+		hf, err := hurlfile.Parse(lines)
+		expect.NoErr(t, err)
+
+		diags := diagnostics.RequestBodies(hf, lines, oai)
+		expect.Equals(t, 1, len(diags))
+		expect.Equals(t, `unknown property "age" in $, additional properties aren't allowed`, diags[0].Message)
+		expect.Equals(t, protocol.Range{
+			Start: protocol.Position{Line: 1, Character: 16},
+			End:   protocol.Position{Line: 1, Character: 21},
+		}, diags[0].Range)
+	})
+
+	t.Run("nested required properties", func(t *testing.T) {
+		spec := `{
+			"paths": {"/pets": {"post": {"requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}}}},
+			"components": {"schemas": {
+				"Pet": {"type": "object", "properties": {
+					"category": {"$ref": "#/components/schemas/Named"},
+					"tags": {"type": "array", "items": {"$ref": "#/components/schemas/Named"}}
+				}},
+				"Named": {"type": "object", "required": ["name"], "properties": {"id": {"type": "integer"}, "name": {"type": "string"}}}
+			}}
+		}`
+		oai, err := openapi.Parse("json", []byte(spec))
+		expect.NoErr(t, err)
+		lines := []string{"POST /pets", `{"category": {"id": 1}, "tags": [{"id": 2, "name": "a"}, {"id": 3}]}`}
+		hf, err := hurlfile.Parse(lines)
+		expect.NoErr(t, err)
+
+		diags := diagnostics.RequestBodies(hf, lines, oai)
+		expect.Equals(t, 2, len(diags))
+		expect.Equals(t, `missing required property "name" in $.category`, diags[0].Message)
+		// the object is the value of a key, so it is reported on the key
+		expect.Equals(t, protocol.Range{
+			Start: protocol.Position{Line: 1, Character: 1},
+			End:   protocol.Position{Line: 1, Character: 11},
+		}, diags[0].Range)
+		expect.Equals(t, `missing required property "name" in $.tags[1]`, diags[1].Message)
+		// items have no key, so it is reported on their opening brace
+		expect.Equals(t, protocol.Range{
+			Start: protocol.Position{Line: 1, Character: 57},
+			End:   protocol.Position{Line: 1, Character: 58},
+		}, diags[1].Range)
+	})
+
+	t.Run("swagger 2.0", func(t *testing.T) {
+		contents, err := os.ReadFile("../fixtures/petstore_swagger.yaml")
+		expect.NoErr(t, err)
+		oai, err := openapi.Parse("yaml", contents)
+		expect.NoErr(t, err)
+		lines := []string{"POST {{url}}/v1/pets", `{"id": 1, "name": 2}`}
+		hf, err := hurlfile.Parse(lines)
+		expect.NoErr(t, err)
+
+		diags := diagnostics.RequestBodies(hf, lines, oai)
+		expect.Equals(t, 1, len(diags))
+		expect.Equals(t, "$.name should be string, not number", diags[0].Message)
+	})
+}
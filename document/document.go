@@ -0,0 +1,154 @@
+package document
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/ethancarlsson/hurl-lsp/hurlfile"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// SyncKind is the kind of text document sync the server supports. Both full
+// and incremental changes are handled by ApplyChanges, but incremental means
+// the client doesn't have to send the whole file on every key press.
+const SyncKind = protocol.TextDocumentSyncKindIncremental
+
+// Document is the in memory copy of a text document as the editor sees it,
+// including unsaved changes, along with the hurl file parsed from it.
+type Document struct {
+	URI      string
+	Version  int32
+	Text     string
+	Lines    []string
+	HurlFile *hurlfile.HurlFile
+}
+
+func New(uri string, version int32, text string) *Document {
+	d := &Document{URI: uri, Version: version, HurlFile: &hurlfile.HurlFile{}}
+	d.setText(text)
+
+	return d
+}
+
+// Parse parses the current text, the previous hurl file is kept if that fails.
+func (d *Document) Parse() error {
+	hf, err := hurlfile.ParseText(d.Text)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", d.URI, err)
+	}
+
+	d.HurlFile = hf
+
+	return nil
+}
+
+// ApplyChanges applies the content changes of a didChange notification in the
+// order they were received.
+func (d *Document) ApplyChanges(version int32, changes []any) error {
+	for _, change := range changes {
+		switch c := change.(type) {
+		case protocol.TextDocumentContentChangeEventWhole:
+			d.setText(c.Text)
+		case protocol.TextDocumentContentChangeEvent:
+			if c.Range == nil {
+				d.setText(c.Text)
+				continue
+			}
+
+			start, end := d.offset(c.Range.Start), d.offset(c.Range.End)
+			if end < start {
+				return fmt.Errorf("invalid change range %v", *c.Range)
+			}
+			d.setText(d.Text[:start] + c.Text + d.Text[end:])
+		default:
+			return fmt.Errorf("unknown content change %T", change)
+		}
+	}
+
+	d.Version = version
+
+	return nil
+}
+
+// offset converts an LSP position into a byte offset in the text. Characters
+// are counted in UTF-16 code units as required by the LSP spec, and positions
+// past the end of a line or the document are clamped to the end.
+func (d *Document) offset(pos protocol.Position) int {
+	line := int(pos.Line)
+	if line >= len(d.Lines) {
+		return len(d.Text)
+	}
+
+	index := 0
+	for _, l := range d.Lines[:line] {
+		index += len(l)
+		if strings.HasPrefix(d.Text[index:], "\r") {
+			index++
+		}
+		index++ // the new line
+	}
+
+	return index + Column(d.Lines, pos)
+}
+
+// Column converts an LSP position into a zero based byte column on its line,
+// the inverse of Position. Characters past the end of the line are clamped
+// to the end, and the character is returned as is for lines past the end.
+func Column(lines []string, pos protocol.Position) int {
+	line := int(pos.Line)
+	if line >= len(lines) {
+		return int(pos.Character)
+	}
+
+	units := 0
+	for i, r := range lines[line] {
+		if units >= int(pos.Character) {
+			return i
+		}
+		units += len(utf16.Encode([]rune{r}))
+	}
+
+	return len(lines[line])
+}
+
+// Position converts a zero based byte column on one of the lines into an LSP
+// position, whose character is counted in UTF-16 code units. Columns past the
+// end of the line are clamped to the end.
+func Position(lines []string, line, col int) protocol.Position {
+	pos := protocol.Position{Line: protocol.UInteger(line), Character: protocol.UInteger(col)}
+	if line < 0 || line >= len(lines) {
+		return pos
+	}
+
+	text := lines[line][:min(max(col, 0), len(lines[line]))]
+	pos.Character = protocol.UInteger(len(utf16.Encode([]rune(text))))
+
+	return pos
+}
+
+// Range converts a range of the lines with zero based byte columns and an
+// exclusive end into an LSP range.
+func Range(lines []string, r hurlfile.SourceRange) protocol.Range {
+	return protocol.Range{
+		Start: Position(lines, r.StartLine, r.StartCol),
+		End:   Position(lines, r.EndLine, r.EndCol),
+	}
+}
+
+func (d *Document) setText(text string) {
+	d.Text = text
+	d.Lines = SplitLines(text)
+}
+
+// SplitLines splits text into lines without their line endings. Unlike a
+// bufio.Scanner, a trailing new line results in a trailing empty line
+// because the cursor can be placed on it.
+func SplitLines(text string) []string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+
+	return lines
+}
@@ -0,0 +1,93 @@
+package document_test
+
+import (
+	"testing"
+
+	"github.com/ethancarlsson/hurl-lsp/document"
+	"github.com/ethancarlsson/hurl-lsp/expect"
+	"github.com/ethancarlsson/hurl-lsp/hurlfile"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestApplyChanges(t *testing.T) {
+	rng := func(sl, sc, el, ec protocol.UInteger) *protocol.Range {
+		return &protocol.Range{
+			Start: protocol.Position{Line: sl, Character: sc},
+			End:   protocol.Position{Line: el, Character: ec},
+		}
+	}
+
+	t.Run("full", func(t *testing.T) {
+		d := document.New("file:///test.hurl", 1, "GET /pets\n")
+		err := d.ApplyChanges(2, []any{
+			protocol.TextDocumentContentChangeEventWhole{Text: "POST /pets\nHTTP 201\n"},
+		})
+		expect.NoErr(t, err)
+
+		expect.Equals(t, int32(2), d.Version)
+		expect.Equals(t, "POST /pets\nHTTP 201\n", d.Text)
+		expect.Equals(t, []string{"POST /pets", "HTTP 201", ""}, d.Lines)
+	})
+
+	t.Run("incremental", func(t *testing.T) {
+		d := document.New("file:///test.hurl", 1, "GET /pets\nHTTP 200\n")
+		err := d.ApplyChanges(2, []any{
+			// insert
+			protocol.TextDocumentContentChangeEvent{Range: rng(0, 9, 0, 9), Text: "/{{id}}"},
+			// replace
+			protocol.TextDocumentContentChangeEvent{Range: rng(1, 5, 1, 8), Text: "404"},
+			// delete across lines
+			protocol.TextDocumentContentChangeEvent{Range: rng(0, 3, 1, 0), Text: " /x\n"},
+		})
+		expect.NoErr(t, err)
+
+		expect.Equals(t, "GET /x\nHTTP 404\n", d.Text)
+		expect.Equals(t, []string{"GET /x", "HTTP 404", ""}, d.Lines)
+	})
+
+	t.Run("utf16 and crlf", func(t *testing.T) {
+		d := document.New("file:///test.hurl", 1, "# 😀 a\r\nGET /\r\n")
+		err := d.ApplyChanges(2, []any{
+			// the emoji is two utf-16 code units
+			protocol.TextDocumentContentChangeEvent{Range: rng(0, 5, 0, 6), Text: "b"},
+			protocol.TextDocumentContentChangeEvent{Range: rng(1, 5, 1, 5), Text: "pets"},
+		})
+		expect.NoErr(t, err)
+
+		expect.Equals(t, "# 😀 b\r\nGET /pets\r\n", d.Text)
+		expect.Equals(t, []string{"# 😀 b", "GET /pets", ""}, d.Lines)
+	})
+
+	t.Run("past the end", func(t *testing.T) {
+		d := document.New("file:///test.hurl", 1, "GET /")
+		err := d.ApplyChanges(2, []any{
+			protocol.TextDocumentContentChangeEvent{Range: rng(0, 40, 3, 0), Text: "pets"},
+		})
+		expect.NoErr(t, err)
+
+		expect.Equals(t, "GET /pets", d.Text)
+	})
+}
+
+func TestRange(t *testing.T) {
+	lines := []string{"GET /é/{{id}}", "# 😀 {{x}}"}
+	pos := func(line, char protocol.UInteger) protocol.Position {
+		return protocol.Position{Line: line, Character: char}
+	}
+
+	// é is 2 bytes and 1 UTF-16 unit, 😀 is 4 bytes and 2 units
+	expect.Equals(t,
+		protocol.Range{Start: pos(0, 7), End: pos(0, 13)},
+		document.Range(lines, hurlfile.SourceRange{StartLine: 0, StartCol: 8, EndLine: 0, EndCol: 14}),
+	)
+	expect.Equals(t,
+		protocol.Range{Start: pos(1, 5), End: pos(1, 10)},
+		document.Range(lines, hurlfile.SourceRange{StartLine: 1, StartCol: 7, EndLine: 1, EndCol: 12}),
+	)
+	expect.Equals(t, pos(1, 10), document.Position(lines, 1, 100))
+
+	expect.Equals(t, 8, document.Column(lines, pos(0, 7)))
+	expect.Equals(t, 7, document.Column(lines, pos(1, 5)))
+	expect.Equals(t, len(lines[1]), document.Column(lines, pos(1, 100)))
+	expect.Equals(t, 3, document.Column(lines, pos(5, 3)))
+}
@@ -0,0 +1,68 @@
+package document
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Store keeps every document the editor has open, keyed by URI.
+type Store struct {
+	mu   sync.RWMutex
+	docs map[string]*Document
+}
+
+func NewStore() *Store {
+	return &Store{docs: map[string]*Document{}}
+}
+
+// Open adds the document to the store, replacing it if it is already open,
+// and parses it.
+func (s *Store) Open(uri string, version int32, text string) (*Document, error) {
+	d := New(uri, version, text)
+	err := d.Parse()
+
+	s.mu.Lock()
+	s.docs[uri] = d
+	s.mu.Unlock()
+
+	return d, err
+}
+
+// Change applies the changes to a copy of an open document, reparses it and
+// replaces the document with it. Documents returned by Get are never
+// modified, so handlers can keep reading them while the editor types.
+func (s *Store) Change(uri string, version int32, changes []any) (*Document, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.docs[uri]
+	if !ok {
+		return nil, fmt.Errorf("document %s has not been opened", uri)
+	}
+
+	next := *d
+	if err := next.ApplyChanges(version, changes); err != nil {
+		return d, fmt.Errorf("failed to apply changes to %s: %w", uri, err)
+	}
+
+	err := next.Parse()
+	s.docs[uri] = &next
+
+	return &next, err
+}
+
+// Close evicts the document from the store.
+func (s *Store) Close(uri string) {
+	s.mu.Lock()
+	delete(s.docs, uri)
+	s.mu.Unlock()
+}
+
+func (s *Store) Get(uri string) (*Document, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	d, ok := s.docs[uri]
+
+	return d, ok
+}
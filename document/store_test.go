@@ -0,0 +1,76 @@
+package document_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/ethancarlsson/hurl-lsp/document"
+	"github.com/ethancarlsson/hurl-lsp/expect"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestStore(t *testing.T) {
+	s := document.NewStore()
+
+	_, err := s.Open("file:///a.hurl", 1, "GET /a\n")
+	expect.NoErr(t, err)
+	_, err = s.Open("file:///b.hurl", 1, "POST /b\nHTTP 201\n")
+	expect.NoErr(t, err)
+
+	_, err = s.Change("file:///a.hurl", 2, []any{
+		protocol.TextDocumentContentChangeEventWhole{Text: "PUT /a\n"},
+	})
+	expect.NoErr(t, err)
+
+	a, ok := s.Get("file:///a.hurl")
+	expect.Equals(t, true, ok)
+	expect.Equals(t, int32(2), a.Version)
+	expect.Equals(t, "PUT", a.HurlFile.Entries[0].Request.Method.Name)
+
+	b, ok := s.Get("file:///b.hurl")
+	expect.Equals(t, true, ok)
+	expect.Equals(t, int32(1), b.Version)
+	expect.Equals(t, "POST", b.HurlFile.Entries[0].Request.Method.Name)
+
+	s.Close("file:///a.hurl")
+	_, ok = s.Get("file:///a.hurl")
+	expect.Equals(t, false, ok)
+
+	_, err = s.Change("file:///a.hurl", 3, []any{
+		protocol.TextDocumentContentChangeEventWhole{Text: "GET /a\n"},
+	})
+	expect.Err(t, err)
+}
+
+func TestStoreChangeKeepsSnapshots(t *testing.T) {
+	s := document.NewStore()
+	before, err := s.Open("file:///a.hurl", 1, "GET /a\n")
+	expect.NoErr(t, err)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 100 {
+			d, _ := s.Get("file:///a.hurl")
+			_ = d.Text + d.Lines[0] + d.HurlFile.Entries[0].Request.Method.Name
+		}
+	}()
+
+	for i := range 100 {
+		_, err := s.Change("file:///a.hurl", int32(i+2), []any{
+			protocol.TextDocumentContentChangeEventWhole{Text: fmt.Sprintf("PUT /%d\n", i)},
+		})
+		expect.NoErr(t, err)
+	}
+	wg.Wait()
+
+	expect.Equals(t, int32(1), before.Version)
+	expect.Equals(t, "GET /a\n", before.Text)
+	expect.Equals(t, "GET", before.HurlFile.Entries[0].Request.Method.Name)
+
+	after, _ := s.Get("file:///a.hurl")
+	expect.Equals(t, int32(101), after.Version)
+	expect.Equals(t, "PUT /99\n", after.Text)
+}
@@ -0,0 +1,316 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ethancarlsson/hurl-lsp/hurlfile"
+)
+
+type Options struct {
+	// Indent is used to indent JSON bodies
+	Indent string
+}
+
+// Document formats the whole hurl file. Apart from JSON bodies only
+// whitespace is changed, every other line is printed in the same order so
+// nothing, including comments and lines the parser didn't understand, is lost.
+func Document(hf *hurlfile.HurlFile, lines []string, opts Options) string {
+	p := newPrinter(hf, lines, opts)
+	cursor := 0
+	for i, entry := range hf.Entries {
+		p.trivia(cursor, entry.Range.StartLine, i > 0)
+		cursor = p.entry(entry) + 1
+	}
+	p.trivia(cursor, len(lines), len(hf.Entries) > 0)
+
+	return p.String()
+}
+
+// Range formats the entries that overlap the lines from start to end. It
+// returns the formatted text and the lines it replaces, ok is false when no
+// entries overlap.
+func Range(hf *hurlfile.HurlFile, lines []string, start, end int, opts Options) (text string, from, to int, ok bool) {
+	p := newPrinter(hf, lines, opts)
+	from, to = -1, -1
+	for _, entry := range hf.Entries {
+		if entry.Range.EndLine < start || entry.Range.StartLine > end {
+			continue
+		}
+
+		if from == -1 {
+			from = entry.Range.StartLine
+		} else {
+			p.trivia(to+1, entry.Range.StartLine, true)
+		}
+		to = p.entry(entry)
+	}
+
+	if from == -1 {
+		return "", 0, 0, false
+	}
+
+	return p.String(), from, to, true
+}
+
+type printer struct {
+	hf    *hurlfile.HurlFile
+	lines []string
+	opts  Options
+	out   []string
+	// first is the first token of every line with tokens
+	first map[int]hurlfile.Token
+}
+
+func newPrinter(hf *hurlfile.HurlFile, lines []string, opts Options) *printer {
+	if opts.Indent == "" {
+		opts.Indent = "    "
+	}
+
+	first := make(map[int]hurlfile.Token, len(lines))
+	for _, t := range hf.Tokens {
+		if _, ok := first[t.Range.StartLine]; !ok {
+			first[t.Range.StartLine] = t
+		}
+	}
+
+	return &printer{hf: hf, lines: lines, opts: opts, first: first}
+}
+
+func (p *printer) firstToken(line int) (hurlfile.Token, bool) {
+	t, ok := p.first[line]
+
+	return t, ok
+}
+
+func (p *printer) String() string {
+	return strings.Join(p.out, "\n") + "\n"
+}
+
+// trivia prints the comments and stray lines between entries. Blank lines are
+// collapsed into one, and separate tells whether the following entry needs a
+// blank line before it.
+func (p *printer) trivia(from, to int, separate bool) {
+	blank := separate
+	for i := from; i < to && i < len(p.lines); i++ {
+		trim := strings.TrimSpace(p.lines[i])
+		if trim == "" {
+			blank = len(p.out) > 0
+			continue
+		}
+
+		if blank {
+			p.out = append(p.out, "")
+			blank = false
+		}
+
+		if isComment(trim) {
+			p.out = append(p.out, trim)
+		} else {
+			// not understood by the parser, keep as is
+			p.out = append(p.out, strings.TrimRight(p.lines[i], " \t"))
+		}
+	}
+
+	if blank && to < len(p.lines) {
+		p.out = append(p.out, "")
+	}
+}
+
+// entry prints the entry up to its last line of content and returns the last
+// line it printed, so that comments after it are printed with the next entry
+// and lines of a body that runs past it aren't printed twice.
+func (p *printer) entry(entry hurlfile.Entry) int {
+	req := entry.Request
+	end := p.lastContentLine(entry.Range.StartLine, entry.Range.EndLine)
+	last := end
+
+	sections := req.Sections
+	var bodies []bodySpan
+	if len(req.Body.Value) > 0 {
+		bodies = append(bodies, bodySpan{req.Body.Range.StartLine, req.Body.Range.EndLine})
+	}
+
+	resp := entry.Response
+	if resp != nil {
+		sections = append(append([]hurlfile.Section{}, sections...), resp.Sections...)
+		if resp.Body.Value != "" {
+			bodies = append(bodies, bodySpan{resp.Body.Range.StartLine, resp.Body.Range.EndLine})
+		}
+	}
+
+	for i := entry.Range.StartLine; i <= end; i++ {
+		raw := p.lines[i]
+		trim := strings.TrimSpace(raw)
+
+		if body, ok := bodyAt(bodies, i); ok {
+			p.body(body)
+			i = body.end
+			last = max(last, body.end)
+			continue
+		}
+
+		if sec, ok := sectionAt(sections, i); ok && i != sec.Range.StartLine {
+			if trim == "" {
+				continue
+			}
+			p.sectionLine(sec, i)
+			continue
+		}
+
+		first, ok := p.firstToken(i)
+		switch {
+		case trim == "":
+			// blank lines are only kept between entries
+		case isComment(trim):
+			p.out = append(p.out, trim)
+		case ok && (first.Kind == hurlfile.TokenMethod || first.Kind == hurlfile.TokenVersion):
+			p.out = append(p.out, strings.Join(strings.Fields(trim), " "))
+		case ok && first.Kind == hurlfile.TokenKey:
+			k, v := splitKeyValue(trim)
+			p.out = append(p.out, k+": "+v)
+		default:
+			p.out = append(p.out, trim)
+		}
+	}
+
+	return last
+}
+
+// sectionLine prints a line in a section, aligning the values of key-value
+// sections.
+func (p *printer) sectionLine(sec hurlfile.Section, i int) {
+	trim := strings.TrimSpace(p.lines[i])
+	first, ok := p.firstToken(i)
+	if !ok || (first.Kind != hurlfile.TokenKey && first.Kind != hurlfile.TokenVariable) {
+		p.out = append(p.out, trim)
+		return
+	}
+
+	width := 0
+	for _, pair := range sec.Pairs {
+		width = max(width, len(pair.Key.Value))
+	}
+
+	k, v := splitKeyValue(trim)
+	p.out = append(p.out, fmt.Sprintf("%s:%s %s", k, strings.Repeat(" ", width-len(k)), v))
+}
+
+type bodySpan struct {
+	start, end int
+}
+
+// body pretty prints JSON bodies. Other bodies are printed as they are
+// because whitespace is significant in them.
+func (p *printer) body(body bodySpan) {
+	lines := p.lines[body.start : body.end+1]
+	if body.end == len(p.lines)-1 && p.lines[body.end] == "" {
+		// an unterminated body runs to the end of the file, the empty last
+		// line is the final new line that String adds back
+		lines = lines[:len(lines)-1]
+	}
+	text := strings.Join(lines, "\n")
+	trim := strings.TrimSpace(text)
+	if (strings.HasPrefix(trim, "{") && !strings.HasPrefix(trim, "{{")) || strings.HasPrefix(trim, "[") {
+		if pretty, ok := prettyJSON(trim, p.opts.Indent); ok {
+			p.out = append(p.out, strings.Split(pretty, "\n")...)
+			return
+		}
+	}
+
+	p.out = append(p.out, lines...)
+}
+
+func (p *printer) lastContentLine(start, end int) int {
+	end = min(end, len(p.lines)-1)
+	for end > start {
+		trim := strings.TrimSpace(p.lines[end])
+		if trim != "" && !isComment(trim) {
+			break
+		}
+		end--
+	}
+
+	return end
+}
+
+// prettyJSON indents JSON that may contain {{templates}}. Templates are
+// swapped for placeholders that keep the JSON valid while it's indented, in
+// a string when they are used as a value.
+func prettyJSON(text, indent string) (string, bool) {
+	type template struct {
+		text, placeholder string
+	}
+	templates := []template{}
+	var b strings.Builder
+	inString := false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if strings.HasPrefix(text[i:], "{{") {
+			if end := strings.Index(text[i:], "}}"); end >= 0 {
+				placeholder := fmt.Sprintf("__hurl_template_%d__", len(templates))
+				if !inString {
+					placeholder = `"` + placeholder + `"`
+				}
+				templates = append(templates, template{text[i : i+end+2], placeholder})
+				b.WriteString(placeholder)
+				i += end + 1
+				continue
+			}
+		}
+
+		switch {
+		case c == '\\' && inString && i+1 < len(text):
+			b.WriteByte(c)
+			i++
+			c = text[i]
+		case c == '"':
+			inString = !inString
+		}
+		b.WriteByte(c)
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, []byte(b.String()), "", indent); err != nil {
+		return "", false
+	}
+
+	pretty := out.String()
+	for _, tmpl := range templates {
+		pretty = strings.Replace(pretty, tmpl.placeholder, tmpl.text, 1)
+	}
+
+	return pretty, true
+}
+
+func bodyAt(bodies []bodySpan, line int) (bodySpan, bool) {
+	for _, body := range bodies {
+		if line >= body.start && line <= body.end {
+			return body, true
+		}
+	}
+
+	return bodySpan{}, false
+}
+
+func sectionAt(sections []hurlfile.Section, line int) (hurlfile.Section, bool) {
+	for _, sec := range sections {
+		if line >= sec.Range.StartLine && line <= sec.Range.EndLine {
+			return sec, true
+		}
+	}
+
+	return hurlfile.Section{}, false
+}
+
+func splitKeyValue(line string) (string, string) {
+	k, v, _ := strings.Cut(line, ":")
+
+	return strings.TrimSpace(k), strings.TrimSpace(v)
+}
+
+func isComment(trim string) bool {
+	return strings.HasPrefix(trim, "#")
+}
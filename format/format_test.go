@@ -0,0 +1,145 @@
+package format_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethancarlsson/hurl-lsp/document"
+	"github.com/ethancarlsson/hurl-lsp/expect"
+	"github.com/ethancarlsson/hurl-lsp/format"
+	"github.com/ethancarlsson/hurl-lsp/hurlfile"
+)
+
+func parse(t *testing.T, text string) (*hurlfile.HurlFile, []string) {
+	t.Helper()
+	lines := document.SplitLines(text)
+	hf, err := hurlfile.Parse(lines)
+	expect.NoErr(t, err)
+
+	return hf, lines
+}
+
+func TestDocument(t *testing.T) {
+	input := strings.Join([]string{
+		"# Create a pet",
+		"",
+		"",
+		"	 POST   {{url}}/pets",
+		"Content-Type:application/json",
+		"{\"name\": \"{{name}}\", \"age\": {{age}}}",
+		"HTTP/1.1   201",
+		"[Captures]",
+		"id: jsonpath \"$.id\"",
+		"  status_code:   status",
+		"# fetch it",
+		"GET {{url}}/pets/{{id}}",
+		"",
+		"HTTP 200",
+		"```",
+		"  raw  ",
+		"bar\t",
+		"```",
+		"",
+		"",
+	}, "\n")
+
+	expected := strings.Join([]string{
+		"# Create a pet",
+		"",
+		"POST {{url}}/pets",
+		"Content-Type: application/json",
+		"{",
+		"  \"name\": \"{{name}}\",",
+		"  \"age\": {{age}}",
+		"}",
+		"HTTP/1.1 201",
+		"[Captures]",
+		"id:          jsonpath \"$.id\"",
+		"status_code: status",
+		"",
+		"# fetch it",
+		"GET {{url}}/pets/{{id}}",
+		"HTTP 200",
+		"```",
+		"  raw  ",
+		"bar\t",
+		"```",
+		"",
+	}, "\n")
+
+	hf, lines := parse(t, input)
+	actual := format.Document(hf, lines, format.Options{Indent: "  "})
+	expect.Equals(t, expected, actual)
+
+	// formatting again changes nothing
+	hf, lines = parse(t, actual)
+	expect.Equals(t, expected, format.Document(hf, lines, format.Options{Indent: "  "}))
+}
+
+func TestDocumentKeepsUnknownLines(t *testing.T) {
+	input := "GET https://example.com\nHTTP 200\n[Cap]\nnot a capture\n"
+	hf, lines := parse(t, input)
+
+	expect.Equals(t, input, format.Document(hf, lines, format.Options{}))
+}
+
+func TestDocumentKeepsRawBodies(t *testing.T) {
+	input := "POST http://a\n```\nfoo   \nbar\t\n```\n"
+	hf, lines := parse(t, input)
+
+	expect.Equals(t, input, format.Document(hf, lines, format.Options{}))
+}
+
+func TestRange(t *testing.T) {
+	input := strings.Join([]string{
+		"GET   https://example.com/a",
+		"HTTP 200",
+		"",
+		"POST   https://example.com/b",
+		"{\"a\":1}",
+		"",
+		"GET   https://example.com/c",
+	}, "\n")
+	hf, lines := parse(t, input)
+
+	text, from, to, ok := format.Range(hf, lines, 4, 4, format.Options{})
+	expect.Equals(t, true, ok)
+	expect.Equals(t, 3, from)
+	expect.Equals(t, 4, to)
+	expect.Equals(t, "POST https://example.com/b\n{\n    \"a\": 1\n}\n", text)
+
+	hf, lines = parse(t, "# no entries\n")
+	_, _, _, ok = format.Range(hf, lines, 0, 0, format.Options{})
+	expect.Equals(t, false, ok)
+}
+
+func TestDocumentUnterminatedBody(t *testing.T) {
+	// the body runs to the end of the file, including the lines after it
+	hf, lines := parse(t, "GET /a\n[Asserts]\n```\n`x`\nGET /a\n#")
+
+	expect.Equals(t, "GET /a\n[Asserts]\n```\n`x`\nGET /a\n#\n", format.Document(hf, lines, format.Options{}))
+}
+
+func TestDocumentIsIdempotent(t *testing.T) {
+	inputs := []string{
+		"GET /a\n[Asserts]\n```\n`x`\nGET /a\n#",
+		"GET /a\nHTTP 200\n```\nraw",
+		"GET /a\nHTTP 200\n```\nraw\n",
+		"POST /a\n{\"a\": 1\n# not json\n",
+		"# only comments\n\n\n# and blank lines\n",
+		"GET /a\n\n# between\n\nGET /b\n# trailing\n\n",
+		"POST /a\n```\n\n```\n",
+		"POST http://a\n```\nfoo   \nbar\t\n```\n",
+		"",
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			hf, lines := parse(t, input)
+			once := format.Document(hf, lines, format.Options{})
+
+			hf, lines = parse(t, once)
+			expect.Equals(t, once, format.Document(hf, lines, format.Options{}))
+		})
+	}
+}
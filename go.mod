@@ -3,13 +3,13 @@ module github.com/ethancarlsson/hurl-lsp
 go 1.25.3
 
 require (
+	github.com/goccy/go-yaml v1.18.0
 	github.com/tliron/commonlog v0.2.21
 	github.com/tliron/glsp v0.2.2
 )
 
 require (
 	github.com/aymanbagabas/go-osc52/v2 v2.0.1 // indirect
-	github.com/goccy/go-yaml v1.18.0 // indirect
 	github.com/gorilla/websocket v1.5.4-0.20250319132907-e064f32e3674 // indirect
 	github.com/iancoleman/strcase v0.3.0 // indirect
 	github.com/lucasb-eyer/go-colorful v1.3.0 // indirect
@@ -0,0 +1,173 @@
+package hover
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ethancarlsson/hurl-lsp/builtin"
+	"github.com/ethancarlsson/hurl-lsp/hurlfile"
+	"github.com/ethancarlsson/hurl-lsp/openapi"
+)
+
+// Hurl returns the markdown documentation of the section name, query, filter,
+// predicate or captured variable under the cursor, or "" if there is nothing
+// to document.
+func Hurl(hf *hurlfile.HurlFile, lines []string, line, col int) string {
+	if tmpl, ok := hf.TemplateAt(line, col); ok {
+		return variable(hf, lines, tmpl)
+	}
+
+	sec, ok := hf.SectionAt(line)
+	if !ok || line >= len(lines) {
+		return ""
+	}
+
+	if line == sec.Range.StartLine {
+		if desc, ok := builtin.Sections[sec.Name.Value]; ok {
+			return markdown("["+sec.Name.Value+"]", "section", desc)
+		}
+
+		return ""
+	}
+
+	if name, onValue, ok := hf.OptionAt(line, col); ok && !onValue {
+		if desc, ok := builtin.Options[name]; ok {
+			return fmt.Sprintf("**%s** _(option)_\n\n%s\n\n`type: %s`", name, desc.Description, desc.Type)
+		}
+
+		return ""
+	}
+
+	query, filters, pred, ok := queryAt(sec, line)
+	if !ok {
+		return ""
+	}
+
+	at := func(r hurlfile.SourceRange) bool {
+		return col >= r.StartCol && col < r.EndCol
+	}
+
+	if at(query.Name.Range) {
+		return documented(query.Name.Value, "query", builtin.Queries)
+	}
+
+	for _, f := range filters {
+		if at(f.Name.Range) {
+			return documented(f.Name.Value, "filter", builtin.Filters)
+		}
+	}
+
+	if pred == nil {
+		return ""
+	}
+
+	if pred.Not != nil && at(*pred.Not) {
+		return documented("not", "predicate", builtin.Predicates)
+	}
+
+	if at(pred.Name.Range) {
+		return documented(pred.Name.Value, "predicate", builtin.Predicates)
+	}
+
+	return ""
+}
+
+// queryAt returns the parts of the assert or capture on the line of the
+// section, captures have no predicate
+func queryAt(sec hurlfile.Section, line int) (hurlfile.Query, []hurlfile.Filter, *hurlfile.Predicate, bool) {
+	for _, a := range sec.Asserts {
+		if a.Range.StartLine == line {
+			return a.Query, a.Filters, a.Predicate, true
+		}
+	}
+
+	for _, c := range sec.Captures {
+		if c.Range.StartLine == line {
+			return c.Query, c.Filters, nil, true
+		}
+	}
+
+	return hurlfile.Query{}, nil, nil, false
+}
+
+func documented(name, kind string, descs map[string]builtin.Desc) string {
+	if desc, ok := descs[name]; ok {
+		return markdown(name, kind, desc)
+	}
+
+	return ""
+}
+
+// variable documents where a captured variable comes from and its type
+func variable(hf *hurlfile.HurlFile, lines []string, tmpl hurlfile.Ranged[string]) string {
+	capture, ok := hf.CaptureOf(tmpl)
+	if !ok || capture.Range.StartLine >= len(lines) {
+		return ""
+	}
+
+	end := capture.Query.Range.EndCol
+	if n := len(capture.Filters); n > 0 {
+		end = capture.Filters[n-1].Range.EndCol
+	}
+	query := lines[capture.Range.StartLine][capture.Query.Range.StartCol:end]
+
+	return fmt.Sprintf(
+		"**%s** _(variable)_\n\nCaptured on line %d with `%s`\n\n`type: %s`",
+		tmpl.Value, capture.Range.StartLine+1, query, capture.Type(),
+	)
+}
+
+func markdown(name, kind string, desc builtin.Desc) string {
+	md := fmt.Sprintf("**%s** _(%s)_\n\n%s", name, kind, desc.Desctiption)
+	if desc.Detail.In != "" || desc.Detail.Out != "" {
+		md += fmt.Sprintf("\n\n`%s`", desc.Detail.String())
+	}
+
+	return md
+}
+
+// Operation returns the markdown documentation of an openapi operation
+func Operation(op openapi.Op) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s %s**", strings.ToUpper(op.Method), op.Path)
+	if op.Detail.Summary != "" {
+		fmt.Fprintf(&b, "\n\n%s", op.Detail.Summary)
+	}
+	if op.Detail.Description != "" && op.Detail.Description != op.Detail.Summary {
+		fmt.Fprintf(&b, "\n\n%s", op.Detail.Description)
+	}
+
+	if len(op.Detail.Parameters) > 0 {
+		b.WriteString("\n\n**Parameters**\n")
+		for _, param := range op.Detail.Parameters {
+			attrs := []string{param.In}
+			if param.Schema.Type != "" {
+				attrs = append(attrs, param.Schema.Type)
+			}
+			if param.Required {
+				attrs = append(attrs, "required")
+			}
+
+			fmt.Fprintf(&b, "\n- `%s` (%s)", param.Name, strings.Join(attrs, ", "))
+			if param.Description != "" {
+				fmt.Fprintf(&b, ": %s", param.Description)
+			}
+		}
+	}
+
+	if len(op.Detail.Responses) > 0 {
+		b.WriteString("\n\n**Responses**\n")
+		codes := make([]string, 0, len(op.Detail.Responses))
+		for code := range op.Detail.Responses {
+			codes = append(codes, code)
+		}
+		slices.Sort(codes)
+
+		for _, code := range codes {
+			fmt.Fprintf(&b, "\n- `%s`: %s", code, op.Detail.Responses[code].Description)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,92 @@
+package hover_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ethancarlsson/hurl-lsp/expect"
+	"github.com/ethancarlsson/hurl-lsp/hover"
+	"github.com/ethancarlsson/hurl-lsp/hurlfile"
+	"github.com/ethancarlsson/hurl-lsp/openapi"
+)
+
+func TestHurl(t *testing.T) {
+	lines := []string{
+		"GET /pets", // 0
+		"[Options]", // 1
+		"insecure: true",
+		"HTTP 200",   // 3
+		"[Captures]", // 4
+		`id: jsonpath "$[0].id" toInt`,
+		"[Asserts]", // 6
+		`jsonpath "$[0].name" split "," count not == 2`,
+		"GET /pets/{{id}}", // 8
+		"HTTP 200",         // 9
+		"[Asserts]",
+		`header "Content-Type" replace "; " "," contains "json"`, // 11
+	}
+	hf, err := hurlfile.Parse(lines)
+	expect.NoErr(t, err)
+
+	tests := []struct {
+		line, col int
+		expected  string
+	}{
+		{1, 3, "**[Options]** _(section)_\n\nOptions that only apply to this entry e.g. `insecure: true` or `retry: 3`."},
+		{4, 0, "**[Captures]** _(section)_\n\nCaptures values from the response into variables that can be used in the following entries e.g. `id: jsonpath \"$.id\"`."},
+		{5, 6, "**jsonpath** _(query)_\n\nEvaluates a JSONPath expression against the response body e.g. `jsonpath \"$.id\"`.\n\n`in: expression, out any`"},
+		{5, 25, "**toInt** _(filter)_\n\nConverts value to integer number.\n\n`in: string|number, out number`"},
+		{7, 0, "**jsonpath** _(query)_\n\nEvaluates a JSONPath expression against the response body e.g. `jsonpath \"$.id\"`.\n\n`in: expression, out any`"},
+		{7, 22, "**split** _(filter)_\n\nSplits to a list of strings around occurrences of the specified delimiter.\n\n`in: string, out collection`"},
+		{7, 38, "**not** _(predicate)_\n\nNegates the predicate that follows it e.g. `not contains \"error\"`.\n\n`in: predicate, out bool`"},
+		{7, 42, "**==** _(predicate)_\n\nChecks that the value is equal to the expected value.\n\n`in: any, out bool`"},
+		{8, 12, "**id** _(variable)_\n\nCaptured on line 6 with `jsonpath \"$[0].id\" toInt`\n\n`type: number`"},
+		{11, 44, "**contains** _(predicate)_\n\nChecks that the value contains the expected substring.\n\n`in: string|bytes, out bool`"},
+		// the capture name
+		{5, 0, ""},
+		// in the quoted arguments of a filter
+		{11, 32, ""},
+		// in the quoted expression
+		{7, 12, ""},
+		{2, 1, "**insecure** _(option)_\n\nAllows insecure SSL connections, the certificate isn't verified.\n\n`type: boolean`"},
+		// an option value
+		{2, 11, ""},
+		// not in an assert, capture or option
+		{0, 1, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d:%d", tt.line, tt.col), func(t *testing.T) {
+			expect.Equals(t, tt.expected, hover.Hurl(hf, lines, tt.line, tt.col))
+		})
+	}
+}
+
+func TestOperation(t *testing.T) {
+	op := openapi.Op{
+		Method: "get",
+		Path:   "/pet/findByStatus",
+		Detail: openapi.OpDetail{
+			Summary:     "Finds Pets by status.",
+			Description: "Multiple status values can be provided with comma separated strings.",
+			Parameters: openapi.OpParams{
+				{Name: "status", In: "query", Description: "Status values", Schema: openapi.Schema{Type: "string"}},
+				{Name: "limit", In: "query", Required: true},
+			},
+			Responses: map[string]openapi.OpResponse{
+				"default": {Description: "Unexpected error"},
+				"200":     {Description: "successful operation"},
+			},
+		},
+	}
+
+	expect.Equals(t, "**GET /pet/findByStatus**\n\n"+
+		"Finds Pets by status.\n\n"+
+		"Multiple status values can be provided with comma separated strings.\n\n"+
+		"**Parameters**\n\n"+
+		"- `status` (query, string): Status values\n"+
+		"- `limit` (query, required)\n\n"+
+		"**Responses**\n\n"+
+		"- `200`: successful operation\n"+
+		"- `default`: Unexpected error", hover.Operation(op))
+}
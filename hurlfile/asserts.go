@@ -0,0 +1,226 @@
+package hurlfile
+
+import (
+	"strings"
+
+	"github.com/ethancarlsson/hurl-lsp/builtin"
+)
+
+// The ranges of assertions and their parts are zero based and the end is exclusive
+
+type Assertion struct {
+	Query   Query
+	Filters []Filter
+	// Predicate is nil when the line has no predicate
+	Predicate *Predicate
+	Range     SourceRange
+}
+
+// Query selects a value from the response e.g. jsonpath "$.id"
+type Query struct {
+	Name Ranged[string]
+	// Arg is the argument of queries like header "Content-Type", nil for
+	// queries without one like status
+	Arg   *Value
+	Range SourceRange
+}
+
+type Filter struct {
+	Name  Ranged[string]
+	Args  []Value
+	Range SourceRange
+}
+
+type Predicate struct {
+	// Not is the range of the not keyword, nil when the predicate isn't negated
+	Not  *SourceRange
+	Name Ranged[string]
+	// Value is nil for predicates without one like exists
+	Value *Value
+	Range SourceRange
+}
+
+type ValueKind int
+
+const (
+	ValueString ValueKind = iota
+	ValueNumber
+	ValueBool
+	ValueNull
+	ValueRegex
+	ValueTemplate
+	// ValueOther is anything else e.g. base64,aGVsbG8=; or an unquoted word
+	ValueOther
+)
+
+// Value is a literal in an assert or a capture. Text is the source text, so
+// strings are still quoted.
+type Value struct {
+	Kind  ValueKind
+	Text  string
+	Range SourceRange
+}
+
+// parseAssert parses an assert line and reports any part that is missing or unknown
+func (p *Parser) parseAssert(line string, lineNum int) Assertion {
+	words := lexWords(line, 0)
+	query, filters, i, ok := p.parseQueryChain(line, lineNum, words)
+	assert := Assertion{Query: query, Filters: filters, Range: trimmedRange(line[:valueEnd(line, 0)], lineNum)}
+	if !ok {
+		return assert
+	}
+
+	if i >= len(words) {
+		last := words[len(words)-1]
+		p.errorf(wordRange(lineNum, last), "missing predicate, expected a predicate like == or exists after %q", line[last.start:last.end])
+		return assert
+	}
+
+	pred := &Predicate{Range: wordRange(lineNum, words[i])}
+	if line[words[i].start:words[i].end] == "not" {
+		pred.Not = ptr(wordRange(lineNum, words[i]))
+		i++
+		if i >= len(words) {
+			p.errorf(*pred.Not, "missing predicate after not")
+			return assert
+		}
+	}
+
+	name := line[words[i].start:words[i].end]
+	pred.Name = Ranged[string]{Value: name, Range: wordRange(lineNum, words[i])}
+	pred.Range.EndCol = words[i].end
+	i++
+	if _, ok := builtin.Predicates[name]; !ok || name == "not" {
+		p.errorf(pred.Name.Range, "unknown predicate %q", name)
+		return assert
+	}
+	assert.Predicate = pred
+
+	if predicateTakesValue(name) {
+		if i >= len(words) {
+			p.errorf(pred.Name.Range, "missing value for the %s predicate", name)
+			return assert
+		}
+
+		pred.Value = ptr(value(line, lineNum, words[i]))
+		pred.Range.EndCol = words[i].end
+		i++
+	}
+
+	if i < len(words) {
+		p.errorf(
+			SourceRange{StartLine: lineNum, StartCol: words[i].start, EndLine: lineNum, EndCol: words[len(words)-1].end},
+			"unexpected text after the %s predicate", name,
+		)
+	}
+
+	return assert
+}
+
+// parseQueryChain parses the query and filters at the start of words and
+// returns the index of the first word after them. ok is false when there is
+// no query or it is unknown.
+func (p *Parser) parseQueryChain(line string, lineNum int, words []lexWord) (query Query, filters []Filter, i int, ok bool) {
+	filters = make([]Filter, 0)
+	if len(words) == 0 {
+		return Query{}, filters, 0, false
+	}
+
+	name := line[words[0].start:words[0].end]
+	query = Query{
+		Name:  Ranged[string]{Value: name, Range: wordRange(lineNum, words[0])},
+		Range: wordRange(lineNum, words[0]),
+	}
+	i = 1
+	desc, ok := builtin.Queries[name]
+	if !ok {
+		p.errorf(query.Name.Range, "unknown query %q", name)
+		return query, filters, len(words), false
+	}
+
+	if desc.Detail.In != "" {
+		if i >= len(words) || isKeyword(line, words[i]) {
+			p.errorf(query.Name.Range, "missing %s for the %s query", desc.Detail.In, name)
+		} else {
+			query.Arg = ptr(value(line, lineNum, words[i]))
+			query.Range.EndCol = words[i].end
+			i++
+		}
+	}
+
+	for i < len(words) {
+		name := line[words[i].start:words[i].end]
+		if _, ok := builtin.Filters[name]; !ok || words[i].kind != TokenValue {
+			break
+		}
+
+		filter := Filter{
+			Name:  Ranged[string]{Value: name, Range: wordRange(lineNum, words[i])},
+			Args:  make([]Value, 0),
+			Range: wordRange(lineNum, words[i]),
+		}
+		i++
+		for _, arg := range builtin.FilterArgs[name] {
+			if i >= len(words) || isKeyword(line, words[i]) {
+				p.errorf(filter.Name.Range, "missing %s for the %s filter", arg, name)
+				break
+			}
+
+			filter.Args = append(filter.Args, value(line, lineNum, words[i]))
+			filter.Range.EndCol = words[i].end
+			i++
+		}
+
+		filters = append(filters, filter)
+	}
+
+	return query, filters, i, true
+}
+
+// predicateTakesValue reports whether the predicate compares the query result
+// to a value, predicates like exists and isString only look at the result.
+func predicateTakesValue(name string) bool {
+	return name != "exists" && !strings.HasPrefix(name, "is")
+}
+
+// isKeyword reports whether the word is a filter or predicate rather than an argument
+func isKeyword(line string, w lexWord) bool {
+	if w.kind != TokenValue {
+		return false
+	}
+
+	name := line[w.start:w.end]
+	_, filter := builtin.Filters[name]
+	_, predicate := builtin.Predicates[name]
+
+	return filter || predicate
+}
+
+func value(line string, lineNum int, w lexWord) Value {
+	text := line[w.start:w.end]
+	kind := ValueOther
+	switch {
+	case w.kind == TokenString:
+		kind = ValueString
+	case w.kind == TokenRegex:
+		kind = ValueRegex
+	case w.kind == TokenTemplate:
+		kind = ValueTemplate
+	case reNumber.MatchString(text):
+		kind = ValueNumber
+	case text == "true" || text == "false":
+		kind = ValueBool
+	case text == "null":
+		kind = ValueNull
+	}
+
+	return Value{Kind: kind, Text: text, Range: wordRange(lineNum, w)}
+}
+
+func wordRange(lineNum int, w lexWord) SourceRange {
+	return SourceRange{StartLine: lineNum, StartCol: w.start, EndLine: lineNum, EndCol: w.end}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
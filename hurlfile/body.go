@@ -0,0 +1,228 @@
+package hurlfile
+
+import (
+	"slices"
+	"strings"
+)
+
+const multilineFence = "```"
+
+type BodyKind int
+
+const (
+	BodyJSON BodyKind = iota
+	BodyXML
+	// BodyMultiline is a ``` string, its language hint is in Body.Lang
+	BodyMultiline
+	// BodyString is a `one line` string
+	BodyString
+	BodyBase64
+	BodyHex
+	BodyFile
+	// BodyOther is any other body hurl doesn't know the format of
+	BodyOther
+)
+
+// Body is the typed body of a request or a response. Content is the body
+// without its delimiters, e.g. the lines between the fences of a multiline
+// string or the path of file,data.json; The ranges are zero based and the end
+// is exclusive.
+type Body struct {
+	Kind BodyKind
+	// Lang is the language hint of a multiline string like json or graphql,
+	// it is empty when there isn't one
+	Lang    Ranged[string]
+	Content Ranged[string]
+	Range   SourceRange
+}
+
+var onelineBodyPrefixes = []string{"base64,", "hex,", "file,"}
+
+// onelineBodyKinds are the kinds of onelineBodyPrefixes, in the same order
+var onelineBodyKinds = []BodyKind{BodyBase64, BodyHex, BodyFile}
+
+// isBodyStart reports whether the trimmed line can be the first line of a body
+func isBodyStart(trim string) bool {
+	if strings.HasPrefix(trim, "{{") {
+		// templates are used in header keys
+		return false
+	}
+
+	for _, prefix := range onelineBodyPrefixes {
+		if strings.HasPrefix(trim, prefix) {
+			return true
+		}
+	}
+
+	if trim == "" {
+		return false
+	}
+
+	switch trim[0] {
+	case '{', '[', '<', '`', '"':
+		return true
+	}
+
+	return reJSONScalar.MatchString(trim)
+}
+
+// parseBody consumes the body starting at the current line until the next
+// request or response line. Multiline strings are consumed until their
+// closing fence regardless of what they contain.
+func (p *Parser) parseBody() ([]string, *Body) {
+	first := p.peek()
+	firstLine := p.i
+	trim := strings.TrimSpace(first)
+	p.checkOnelineBody(trim, trimmedRange(first, p.i))
+
+	inMultiline := strings.HasPrefix(trim, multilineFence) &&
+		!(len(trim) >= 2*len(multilineFence) && strings.HasSuffix(trim, multilineFence))
+
+	body := []string{first}
+	p.i++
+	for !p.eof() {
+		raw := p.peek()
+		trim := strings.TrimSpace(raw)
+
+		if inMultiline {
+			body = append(body, raw)
+			p.i++
+			inMultiline = trim != multilineFence
+			continue
+		}
+
+		if reMethodLine.MatchString(trim) || reResponseLine.MatchString(trim) {
+			break
+		}
+
+		body = append(body, raw)
+		p.i++
+	}
+
+	if inMultiline {
+		p.errorf(trimmedRange(first, firstLine), "unterminated multiline string body, expected a closing %s", multilineFence)
+	} else {
+		// trailing blank lines and comments are between entries, not part of the body
+		for len(body) > 1 {
+			last := strings.TrimSpace(body[len(body)-1])
+			if last != "" && !strings.HasPrefix(last, "#") {
+				break
+			}
+			body = body[:len(body)-1]
+		}
+	}
+
+	for i, line := range body {
+		p.recordTemplates(line, firstLine+i)
+		p.tokenizeBody(line, firstLine+i)
+	}
+
+	return body, typeBody(body, firstLine)
+}
+
+// typeBody works out the kind of body from its first line
+func typeBody(body []string, firstLine int) *Body {
+	lastLine := firstLine + len(body) - 1
+	first := body[0]
+	trim := strings.TrimSpace(first)
+	start := countLeadingWhitespace(first)
+	end := len(strings.TrimRightFunc(body[len(body)-1], isSpace))
+	b := &Body{
+		Kind:  BodyOther,
+		Range: SourceRange{StartLine: firstLine, StartCol: start, EndLine: lastLine, EndCol: end},
+	}
+	b.Content = Ranged[string]{Value: joinBody(body, start, end), Range: b.Range}
+
+	// content sets the content to line[from:to] of a one line body
+	content := func(from, to int) {
+		from = start + from
+		to = max(start+to, from)
+		b.Content = Ranged[string]{
+			Value: first[from:to],
+			Range: SourceRange{StartLine: firstLine, StartCol: from, EndLine: firstLine, EndCol: to},
+		}
+	}
+
+	for i, prefix := range onelineBodyPrefixes {
+		if !strings.HasPrefix(trim, prefix) {
+			continue
+		}
+
+		b.Kind = onelineBodyKinds[i]
+		end := strings.Index(trim, ";")
+		if end < 0 {
+			end = len(trim)
+		}
+		from := len(prefix) + countLeadingWhitespace(trim[len(prefix):end])
+		content(from, len(strings.TrimRightFunc(trim[:end], isSpace)))
+
+		return b
+	}
+
+	switch {
+	case strings.HasPrefix(trim, multilineFence):
+		b.Kind = BodyMultiline
+		if len(body) == 1 {
+			// ```one line```
+			content(len(multilineFence), len(trim)-len(multilineFence))
+			return b
+		}
+
+		lang := strings.TrimSpace(trim[len(multilineFence):])
+		langStart := start + strings.Index(first[start:], lang)
+		b.Lang = Ranged[string]{
+			Value: lang,
+			Range: SourceRange{StartLine: firstLine, StartCol: langStart, EndLine: firstLine, EndCol: langStart + len(lang)},
+		}
+
+		inner := body[1:]
+		if strings.TrimSpace(inner[len(inner)-1]) == multilineFence {
+			inner = inner[:len(inner)-1]
+		}
+		b.Content = Ranged[string]{
+			Value: strings.Join(inner, "\n"),
+			Range: SourceRange{StartLine: firstLine + 1, EndLine: firstLine + len(inner)},
+		}
+		if len(inner) > 0 {
+			b.Content.Range.EndCol = len(inner[len(inner)-1])
+		} else {
+			b.Content.Range.EndLine = firstLine + 1
+		}
+	case strings.HasPrefix(trim, "`"):
+		b.Kind = BodyString
+		content(1, max(len(trim)-1, 1))
+	case strings.HasPrefix(trim, "<"):
+		b.Kind = BodyXML
+	case strings.HasPrefix(trim, "{"), strings.HasPrefix(trim, "["), strings.HasPrefix(trim, `"`), reJSONScalar.MatchString(trim):
+		b.Kind = BodyJSON
+	}
+
+	return b
+}
+
+// joinBody joins the lines of the body from start on the first line to end on the last line
+func joinBody(body []string, start, end int) string {
+	if len(body) == 1 {
+		return body[0][start:max(end, start)]
+	}
+
+	lines := slices.Clone(body)
+	lines[0] = lines[0][start:]
+	lines[len(lines)-1] = lines[len(lines)-1][:end]
+
+	return strings.Join(lines, "\n")
+}
+
+func (p *Parser) checkOnelineBody(trim string, rng SourceRange) {
+	for _, prefix := range onelineBodyPrefixes {
+		if strings.HasPrefix(trim, prefix) && !strings.HasSuffix(trim, ";") {
+			p.errorf(rng, "unterminated %s body, expected a closing ;", strings.TrimSuffix(prefix, ","))
+			return
+		}
+	}
+
+	if strings.HasPrefix(trim, "`") && !strings.HasPrefix(trim, multilineFence) &&
+		(len(trim) == 1 || !strings.HasSuffix(trim, "`")) {
+		p.errorf(rng, "unterminated string body, expected a closing `")
+	}
+}
@@ -1,10 +1,115 @@
 package hurlfile
 
+import (
+	"regexp"
+
+	"github.com/ethancarlsson/hurl-lsp/builtin"
+)
+
 const Capture = "Captures"
 
+// VariableCapture is a "name: query filters" line of a [Captures] section.
+// The ranges are zero based and the end is exclusive.
+type VariableCapture struct {
+	Name    Ranged[string]
+	Query   Query
+	Filters []Filter
+	// Redact is set when the value is hidden from the logs with redact
+	Redact bool
+	Range  SourceRange
+}
+
+// reJSONPathCollection matches JSONPath expressions that select more than one
+// node, like wildcards, recursive descent, filters, slices and unions.
+var reJSONPathCollection = regexp.MustCompile(`\[\*\]|\.\*|\.\.|\[\?|\[[^\]]*[:,][^\]]*\]`)
+
+// Type is the type of the captured value as far as it is known without
+// sending the request, e.g. string, number or collection. It is "any" when
+// it depends on the response.
+func (c VariableCapture) Type() string {
+	if n := len(c.Filters); n > 0 {
+		f := c.Filters[n-1]
+		if f.Name.Value == "jsonpath" && len(f.Args) > 0 && reJSONPathCollection.MatchString(f.Args[0].Text) {
+			return "collection"
+		}
+
+		return builtin.Filters[f.Name.Value].Detail.Out
+	}
+
+	if c.Query.Name.Value == "jsonpath" && c.Query.Arg != nil && reJSONPathCollection.MatchString(c.Query.Arg.Text) {
+		return "collection"
+	}
+
+	if desc, ok := builtin.Queries[c.Query.Name.Value]; ok {
+		return desc.Detail.Out
+	}
+
+	return "any"
+}
+
+// parseCapture parses the query and filters of a capture line and reports any
+// part that is missing or unknown.
+func (p *Parser) parseCapture(line string, lineNum int) VariableCapture {
+	kv := keyValue(line, lineNum)
+	capture := VariableCapture{Name: kv.Key, Filters: make([]Filter, 0), Range: kv.Range}
+
+	words := lexWords(line, kv.Value.Range.StartCol)
+	if len(words) == 0 {
+		p.errorf(kv.Range, "missing query for the %s capture, expected a query like jsonpath \"$.id\"", kv.Key.Value)
+		return capture
+	}
+
+	query, filters, i, ok := p.parseQueryChain(line, lineNum, words)
+	capture.Query, capture.Filters = query, filters
+	if !ok {
+		return capture
+	}
+
+	if i < len(words) && words[i].kind == TokenValue && line[words[i].start:words[i].end] == "redact" {
+		capture.Redact = true
+		i++
+	}
+
+	if i < len(words) {
+		p.errorf(
+			SourceRange{StartLine: lineNum, StartCol: words[i].start, EndLine: lineNum, EndCol: words[len(words)-1].end},
+			"unexpected text after the %s capture query, expected filters", kv.Key.Value,
+		)
+	}
+
+	return capture
+}
+
+// CaptureOf returns the capture that defines the variable used by the
+// template, like DefinitionOf.
+func (hf *HurlFile) CaptureOf(tmpl Ranged[string]) (VariableCapture, bool) {
+	def, ok := hf.DefinitionOf(tmpl)
+	if !ok {
+		return VariableCapture{}, false
+	}
+
+	for _, entry := range hf.Entries {
+		if entry.Response == nil {
+			continue
+		}
+
+		for _, section := range entry.Response.Sections {
+			for _, capture := range section.Captures {
+				if capture.Name.Range == def.Range {
+					return capture, true
+				}
+			}
+		}
+	}
+
+	return VariableCapture{}, false
+}
+
 type CaptureVars struct {
 	UseAfter  int
 	Variables []string
+	// Definitions are the ranges of the capture keys, in the same order as Variables
+	Definitions []Ranged[string]
 }
 
 type Captures []CaptureVars
@@ -42,17 +147,37 @@ func (hf *HurlFile) Captures() Captures {
 				continue
 			}
 
-			vars := make([]string, 0, len(section.KeyValues))
-			for k := range section.KeyValues {
-				vars = append(vars, k)
+			vars := make([]string, 0, len(section.Pairs))
+			defs := make([]Ranged[string], 0, len(section.Pairs))
+			for _, pair := range section.Pairs {
+				vars = append(vars, pair.Key.Value)
+				defs = append(defs, pair.Key)
 			}
 
 			caps = append(caps, CaptureVars{
-				UseAfter:  section.Range.EndLine,
-				Variables: vars,
+				UseAfter:    section.Range.EndLine,
+				Variables:   vars,
+				Definitions: defs,
 			})
 		}
 	}
 
 	return caps
 }
+
+// DefinitionOf returns the capture key that defines the variable used by the
+// template. If it is captured more than once the nearest preceding capture is
+// returned.
+func (hf *HurlFile) DefinitionOf(tmpl Ranged[string]) (Ranged[string], bool) {
+	caps := hf.Captures().Before(tmpl.Range.StartLine)
+	for i := len(caps) - 1; i >= 0; i-- {
+		defs := caps[i].Definitions
+		for j := len(defs) - 1; j >= 0; j-- {
+			if defs[j].Value == tmpl.Value {
+				return defs[j], true
+			}
+		}
+	}
+
+	return Ranged[string]{}, false
+}
@@ -0,0 +1,231 @@
+package hurlfile
+
+import "strings"
+
+type NodeKind int
+
+const (
+	NodeFile NodeKind = iota
+	NodeEntry
+	NodeRequest
+	NodeResponse
+	NodeSection
+	NodeBody
+	NodeLine
+	NodeToken
+)
+
+// Node is a node of the concrete syntax tree. Every byte of the source,
+// including whitespace, comments and line endings, belongs to exactly one
+// NodeToken leaf, so printing the leaves in order gives back the source.
+//
+// Lines that don't belong to a more specific node, like blank lines between
+// entries or the headers of a request, are children of the closest node that
+// contains them. The range of a node includes its line endings.
+type Node struct {
+	Kind  NodeKind
+	Range SourceRange
+	// Token and Text are only set on NodeToken leaves
+	Token    Token
+	Text     string
+	Children []*Node
+}
+
+// String returns the source text of the node
+func (n *Node) String() string {
+	var sb strings.Builder
+	n.write(&sb)
+
+	return sb.String()
+}
+
+func (n *Node) write(sb *strings.Builder) {
+	sb.WriteString(n.Text)
+	for _, c := range n.Children {
+		c.write(sb)
+	}
+}
+
+// Leaves returns the tokens of the node in the order they appear
+func (n *Node) Leaves() []*Node {
+	if n.Kind == NodeToken {
+		return []*Node{n}
+	}
+
+	leaves := make([]*Node, 0)
+	for _, c := range n.Children {
+		leaves = append(leaves, c.Leaves()...)
+	}
+
+	return leaves
+}
+
+// span is the lines from start to end that belong to a node of kind
+type span struct {
+	kind       NodeKind
+	start, end int
+	children   []span
+}
+
+func entrySpans(entries []Entry) []span {
+	spans := make([]span, 0, len(entries))
+	for _, entry := range entries {
+		req := entry.Request
+		reqSpan := span{kind: NodeRequest, start: req.Range.StartLine, end: req.Range.EndLine}
+		for _, sec := range req.Sections {
+			reqSpan.children = append(reqSpan.children, span{kind: NodeSection, start: sec.Range.StartLine, end: sec.Range.EndLine})
+		}
+		if len(req.Body.Value) > 0 {
+			reqSpan.children = append(reqSpan.children, span{kind: NodeBody, start: req.Body.Range.StartLine, end: req.Body.Range.EndLine})
+		}
+
+		entrySpan := span{kind: NodeEntry, start: entry.Range.StartLine, end: entry.Range.EndLine, children: []span{reqSpan}}
+		if resp := entry.Response; resp != nil {
+			respSpan := span{kind: NodeResponse, start: resp.Range.StartLine, end: resp.Range.EndLine}
+			for _, sec := range resp.Sections {
+				respSpan.children = append(respSpan.children, span{kind: NodeSection, start: sec.Range.StartLine, end: sec.Range.EndLine})
+			}
+			if resp.Body.Value != "" {
+				respSpan.children = append(respSpan.children, span{kind: NodeBody, start: resp.Body.Range.StartLine, end: resp.Body.Range.EndLine})
+			}
+			entrySpan.children = append(entrySpan.children, respSpan)
+		}
+
+		spans = append(spans, entrySpan)
+	}
+
+	return spans
+}
+
+// buildSyntax builds the concrete syntax tree from the tokens found by the
+// parser, the text between them becomes whitespace, comment or text tokens.
+func (p *Parser) buildSyntax(entries []Entry) *Node {
+	byLine := make([][]Token, len(p.lines))
+	for _, t := range p.tokens {
+		byLine[t.Range.StartLine] = append(byLine[t.Range.StartLine], t)
+	}
+
+	return p.syntaxNode(NodeFile, span{start: 0, end: len(p.lines) - 1, children: entrySpans(entries)}, byLine)
+}
+
+func (p *Parser) syntaxNode(kind NodeKind, s span, byLine [][]Token) *Node {
+	n := &Node{Kind: kind}
+	line := s.start
+	for _, c := range s.children {
+		// children that overlap a previous one or aren't inside the node are
+		// left as lines, so no line is printed twice
+		if c.start < line || c.end > s.end || c.end < c.start {
+			continue
+		}
+
+		for ; line < c.start; line++ {
+			n.Children = append(n.Children, p.syntaxLine(line, byLine[line]))
+		}
+		n.Children = append(n.Children, p.syntaxNode(c.kind, c, byLine))
+		line = c.end + 1
+	}
+
+	for ; line <= s.end; line++ {
+		n.Children = append(n.Children, p.syntaxLine(line, byLine[line]))
+	}
+
+	n.Range = SourceRange{StartLine: s.start, EndLine: s.start}
+	if len(n.Children) > 0 {
+		n.Range.EndLine = n.Children[len(n.Children)-1].Range.EndLine
+		n.Range.EndCol = n.Children[len(n.Children)-1].Range.EndCol
+	}
+
+	return n
+}
+
+func (p *Parser) syntaxLine(lineNum int, tokens []Token) *Node {
+	line := p.lines[lineNum]
+	n := &Node{
+		Kind:  NodeLine,
+		Range: SourceRange{StartLine: lineNum, EndLine: lineNum, EndCol: len(line)},
+	}
+	leaf := func(kind TokenKind, start, end int) {
+		if end <= start {
+			return
+		}
+
+		rng := SourceRange{StartLine: lineNum, StartCol: start, EndLine: lineNum, EndCol: end}
+		n.Children = append(n.Children, &Node{Kind: NodeToken, Range: rng, Token: Token{Kind: kind, Range: rng}, Text: line[start:end]})
+	}
+
+	cursor := 0
+	for _, t := range tokens {
+		if t.Range.StartCol < cursor {
+			// overlaps the previous token
+			continue
+		}
+
+		p.syntaxGap(line, cursor, t.Range.StartCol, leaf)
+		leaf(t.Kind, t.Range.StartCol, t.Range.EndCol)
+		cursor = t.Range.EndCol
+	}
+	p.syntaxGap(line, cursor, len(line), leaf)
+
+	if ending := p.lineEnding(lineNum); ending != "" {
+		rng := SourceRange{StartLine: lineNum, StartCol: len(line), EndLine: lineNum + 1}
+		n.Children = append(n.Children, &Node{Kind: NodeToken, Range: rng, Token: Token{Kind: TokenNewline, Range: rng}, Text: ending})
+		n.Range = SourceRange{StartLine: lineNum, EndLine: lineNum + 1}
+	}
+
+	return n
+}
+
+// syntaxGap splits the text between two tokens into whitespace, comments and
+// text the parser didn't classify, like the colon between a key and a value.
+func (p *Parser) syntaxGap(line string, start, end int, leaf func(kind TokenKind, start, end int)) {
+	for i := start; i < end; {
+		j := i
+		switch {
+		case isSpace(rune(line[i])):
+			for j < end && isSpace(rune(line[j])) {
+				j++
+			}
+			leaf(TokenWhitespace, i, j)
+		case line[i] == '#':
+			j = start + len(strings.TrimRightFunc(line[start:end], isSpace))
+			leaf(TokenComment, i, j)
+		default:
+			for j < end && !isSpace(rune(line[j])) {
+				j++
+			}
+			leaf(TokenText, i, j)
+		}
+		i = j
+	}
+}
+
+func (p *Parser) lineEnding(lineNum int) string {
+	if p.endings != nil {
+		return p.endings[lineNum]
+	}
+
+	if lineNum < len(p.lines)-1 {
+		return "\n"
+	}
+
+	return ""
+}
+
+// splitText splits text into lines without their line endings, which are
+// returned separately so the text can be printed back as it was.
+func splitText(text string) (lines, endings []string) {
+	for {
+		i := strings.Index(text, "\n")
+		if i < 0 {
+			line := strings.TrimSuffix(text, "\r")
+			lines = append(lines, line)
+			endings = append(endings, text[len(line):])
+			return lines, endings
+		}
+
+		line := strings.TrimSuffix(text[:i], "\r")
+		lines = append(lines, line)
+		endings = append(endings, text[len(line):i+1])
+		text = text[i+1:]
+	}
+}
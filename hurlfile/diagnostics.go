@@ -0,0 +1,49 @@
+package hurlfile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity matches the LSP DiagnosticSeverity values
+type Severity int
+
+const (
+	SeverityError Severity = iota + 1
+	SeverityWarning
+	SeverityInformation
+	SeverityHint
+)
+
+// Diagnostic is a problem found while parsing. Unlike most ranges in the AST,
+// the columns of a diagnostic range are zero based and the end is exclusive,
+// so it can be handed to the editor as is.
+type Diagnostic struct {
+	Range    SourceRange
+	Severity Severity
+	Message  string
+}
+
+func (p *Parser) errorf(rng SourceRange, format string, args ...any) {
+	p.diagnostics = append(p.diagnostics, Diagnostic{
+		Range:    rng,
+		Severity: SeverityError,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// trimmedRange is the range of the line without leading or trailing whitespace
+func trimmedRange(line string, lineNum int) SourceRange {
+	start := countLeadingWhitespace(line)
+	end := len(strings.TrimRightFunc(line, isSpace))
+	if end < start {
+		end = start
+	}
+
+	return SourceRange{
+		StartLine: lineNum,
+		StartCol:  start,
+		EndLine:   lineNum,
+		EndCol:    end,
+	}
+}
@@ -20,6 +20,21 @@ func Parse(lines []string) (*HurlFile, error) {
 	return hurlFile, nil
 }
 
+// ParseText parses the text of a hurl file. Unlike Parse the line endings are
+// known, so the syntax tree prints back to exactly the same text.
+func ParseText(text string) (*HurlFile, error) {
+	lines, endings := splitText(text)
+	parser := NewParser(lines)
+	parser.endings = endings
+
+	hurlFile, err := parser.Parse()
+	if err != nil {
+		return &HurlFile{}, fmt.Errorf("parse file: %w", err)
+	}
+
+	return hurlFile, nil
+}
+
 func ParseLines(uri string) ([]string, error) {
 	f, err := os.OpenFile(strings.Replace(uri, "file://", "", 1), os.O_RDONLY, os.ModePerm)
 	if err != nil {
@@ -42,8 +57,18 @@ func ParseLines(uri string) ([]string, error) {
 
 // AST structures
 type HurlFile struct {
-	Entries []Entry
-	Range   SourceRange
+	Entries     []Entry
+	Range       SourceRange
+	Diagnostics []Diagnostic
+	// Templates are the {{variables}} used in the file, in the order they appear
+	Templates []Ranged[string]
+	// Tokens classify the parts of every line the parser understood, in the
+	// order they appear
+	Tokens []Token
+	// Comments are the comment lines and trailing comments, in the order they appear
+	Comments []Ranged[string]
+	// Syntax is the lossless concrete syntax tree of the file
+	Syntax *Node
 }
 
 type SourceRange struct {
@@ -60,10 +85,24 @@ type Entry struct {
 }
 
 type Section struct {
-	Name      Ranged[string]
-	KeyValues map[string]string
-	Range     SourceRange
-	RawLines  []string
+	Name Ranged[string]
+	// Pairs are the key-value lines of key-value sections and captures, in the
+	// order they appear and including duplicates
+	Pairs []KeyValue
+	// Asserts are the lines of an [Asserts] section
+	Asserts []Assertion
+	// Captures are the key-value lines of a [Captures] section
+	Captures []VariableCapture
+	Range    SourceRange
+	RawLines []string
+}
+
+// KeyValue is a "key: value" line of a header or a section. The columns are
+// zero based and the end is exclusive, a trailing comment isn't part of the value.
+type KeyValue struct {
+	Key   Ranged[string]
+	Value Ranged[string]
+	Range SourceRange
 }
 
 type Ranged[T any] struct {
@@ -73,9 +112,14 @@ type Ranged[T any] struct {
 
 // Parser
 type Parser struct {
-	lines []string
-	i     int
-	len   int
+	lines       []string
+	i           int
+	len         int
+	diagnostics []Diagnostic
+	templates   []Ranged[string]
+	tokens      []Token
+	// endings are the line endings when they're known
+	endings []string
 }
 
 func NewParser(lines []string) *Parser {
@@ -117,9 +161,11 @@ func (p *Parser) skipCommentsAndEmpty() {
 
 // Recognizers
 var reMethodLine = regexp.MustCompile(`^[A-Z]+\b(?:\s+.+)?$`)
-var reResponseLine = regexp.MustCompile(`^HTTP[\/\d+]*(?:\.\d+)?\s+\d+`) // e.g. HTTP/1.1 200 or HTTP 400
+var reResponseLine = regexp.MustCompile(`^HTTP(?:/[\d.]*)?(?:\s|$)`) // e.g. HTTP/1.1 200 or HTTP 400
 var reHeaderLine = regexp.MustCompile(`^[^:\s][^:]*\s*:\s*.*$`)
 var reSectionLine = regexp.MustCompile(`^\s*\[([A-Za-z0-9_-]*)\]\s*$`)
+var reKey = regexp.MustCompile(`^(?:[A-Za-z0-9_.\[\]@$-]|\\.|\{\{[^{}]*\}\})+$`)
+var reJSONScalar = regexp.MustCompile(`^(?:true|false|null|-?\d+(?:\.\d+)?(?:[eE][+-]?\d+)?)$`)
 
 func (p *Parser) Parse() (*HurlFile, error) {
 	h := &HurlFile{}
@@ -129,8 +175,14 @@ func (p *Parser) Parse() (*HurlFile, error) {
 			break
 		}
 		// Expect a request line (METHOD ...)
-		// If not a method line, but maybe stray comments/lines - treat as error or skip
 		line := strings.TrimSpace(p.peek())
+		if reResponseLine.MatchString(line) {
+			p.errorf(trimmedRange(p.peek(), p.i), "response without a request, expected a request line like \"GET https://example.org\" first")
+			// consume the whole response so its sections aren't reported line by line
+			p.parseResponse()
+			continue
+		}
+
 		if reMethodLine.MatchString(line) {
 			entry, err := p.parseEntry()
 			if err != nil {
@@ -139,9 +191,9 @@ func (p *Parser) Parse() (*HurlFile, error) {
 			h.Entries = append(h.Entries, *entry)
 			continue
 		}
-		// If we find something else (like a response without request), skip it safely
-		// But in general Hurl files start with a request.
-		// We'll skip unexpected lines to be forgiving.
+
+		// We'll skip unexpected lines to be forgiving but let the user know
+		p.errorf(trimmedRange(p.peek(), p.i), "unexpected line outside of an entry, expected a request line like \"GET https://example.org\"")
 		p.i++
 	}
 
@@ -149,6 +201,17 @@ func (p *Parser) Parse() (*HurlFile, error) {
 	if len(h.Entries) > 0 {
 		h.Range.EndLine = h.Entries[len(h.Entries)-1].Range.EndLine
 	}
+	h.Diagnostics = p.diagnostics
+	h.Templates = p.templates
+	p.tokenizeComments()
+	sortTokens(p.tokens)
+	h.Tokens = p.tokens
+	h.Syntax = p.buildSyntax(h.Entries)
+	for _, leaf := range h.Syntax.Leaves() {
+		if leaf.Token.Kind == TokenComment {
+			h.Comments = append(h.Comments, Ranged[string]{Value: leaf.Text, Range: leaf.Range})
+		}
+	}
 
 	return h, nil
 }
@@ -165,11 +228,7 @@ func (p *Parser) parseEntry() (*Entry, error) {
 	if !p.eof() {
 		ln := strings.TrimSpace(p.peek())
 		if reResponseLine.MatchString(ln) {
-			r, err := p.parseResponse()
-			if err != nil {
-				return nil, err
-			}
-			resp = r
+			resp = p.parseResponse()
 		}
 	}
 	entry := &Entry{
@@ -192,7 +251,7 @@ func (p *Parser) parseEntry() (*Entry, error) {
 }
 
 // parseSection assumes current line is [Name]
-func (p *Parser) parseSection() (*Section, error) {
+func (p *Parser) parseSection(inResponse bool) (*Section, error) {
 	line := p.next()
 	m := reSectionLine.FindStringSubmatch(line)
 	name := ""
@@ -202,10 +261,13 @@ func (p *Parser) parseSection() (*Section, error) {
 
 	startLine := p.i - 1
 	sec := &Section{
-		Name:      Ranged[string]{Value: name, Range: p.computeStringRange(name, countLeadingWhitespace(line))},
-		KeyValues: map[string]string{},
-		Range:     computeLineRange(line, startLine),
+		Name:  Ranged[string]{Value: name, Range: p.computeStringRange(name, countLeadingWhitespace(line))},
+		Range: computeLineRange(line, startLine),
 	}
+	p.checkSectionName(sec.Name.Value, trimmedRange(line, startLine), inResponse)
+	p.tokenizeSectionName(line, startLine)
+	isKeyValue := sec.Name.Value != Assert && (requestSections[name] || responseSections[name])
+
 	// Collect following key-value lines until blank or another section / request/response starts
 	for !p.eof() {
 		raw := p.peek()
@@ -215,17 +277,32 @@ func (p *Parser) parseSection() (*Section, error) {
 			p.i++
 			continue
 		}
-		// stop if next is another section or request/response start
-		if reSectionLine.MatchString(raw) || reMethodLine.MatchString(trim) || reResponseLine.MatchString(trim) {
+		// stop if next is another section, a body or request/response start
+		if reSectionLine.MatchString(raw) || reMethodLine.MatchString(trim) || reResponseLine.MatchString(trim) || isBodyStart(trim) {
 			break
 		}
+		switch {
+		case sec.Name.Value == Assert:
+			p.tokenizeQuery(raw, p.i, 0)
+			sec.Asserts = append(sec.Asserts, p.parseAssert(raw, p.i))
+		case sec.Name.Value == Capture:
+			p.tokenizeCapture(raw, p.i)
+			if reHeaderLine.MatchString(trim) {
+				sec.Captures = append(sec.Captures, p.parseCapture(raw, p.i))
+			}
+		case isKeyValue:
+			p.tokenizeKeyValue(raw, p.i, TokenKey)
+		}
+
 		// parse key-value: expect "key : value" or "key: value"
-		if reHeaderLine.MatchString(raw) {
-			k, v := splitHeader(raw)
-			sec.KeyValues[k] = v
+		if isKeyValue && reHeaderLine.MatchString(trim) {
+			sec.Pairs = append(sec.Pairs, keyValue(raw, p.i))
+		} else if isKeyValue {
+			p.errorf(trimmedRange(raw, p.i), "expected \"key: value\" in [%s] section", name)
 		}
 		// if not a key-value line, treat as raw line included in section raw content and consume
 		sec.RawLines = append(sec.RawLines, raw)
+		p.recordTemplates(raw, p.i)
 		p.i++
 	}
 
@@ -235,6 +312,90 @@ func (p *Parser) parseSection() (*Section, error) {
 	return sec, nil
 }
 
+func (p *Parser) checkSectionName(name string, rng SourceRange, inResponse bool) {
+	switch {
+	case inResponse && responseSections[name], !inResponse && requestSections[name]:
+		return
+	case inResponse && requestSections[name]:
+		p.errorf(rng, "[%s] is a request section, it must come before the response status line", name)
+	case !inResponse && responseSections[name]:
+		p.errorf(rng, "[%s] is a response section, it must come after a response status line like \"HTTP 200\"", name)
+	default:
+		p.errorf(rng, "unknown section [%s]", name)
+	}
+}
+
+// parseHeader consumes the current line as a header, reporting it if the key
+// isn't valid.
+func (p *Parser) parseHeader(raw string) KeyValue {
+	kv := keyValue(raw, p.i)
+	if !reKey.MatchString(kv.Key.Value) {
+		p.errorf(kv.Key.Range, "invalid header name %q", kv.Key.Value)
+	}
+	p.recordTemplates(raw, p.i)
+	p.tokenizeKeyValue(raw, p.i, TokenKey)
+	p.i++
+
+	return kv
+}
+
+// keyValue splits the line at the first colon
+func keyValue(line string, lineNum int) KeyValue {
+	keyStart := countLeadingWhitespace(line)
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		colon = len(line)
+	}
+	keyEnd := keyStart + len(strings.TrimRightFunc(line[keyStart:colon], isSpace))
+
+	valueStart := min(colon+1, len(line))
+	valueStart += countLeadingWhitespace(line[valueStart:])
+	valueEnd := max(valueEnd(line, valueStart), valueStart)
+
+	return KeyValue{
+		Key: Ranged[string]{
+			Value: line[keyStart:keyEnd],
+			Range: SourceRange{StartLine: lineNum, StartCol: keyStart, EndLine: lineNum, EndCol: keyEnd},
+		},
+		Value: Ranged[string]{
+			Value: line[valueStart:valueEnd],
+			Range: SourceRange{StartLine: lineNum, StartCol: valueStart, EndLine: lineNum, EndCol: valueEnd},
+		},
+		Range: SourceRange{StartLine: lineNum, StartCol: keyStart, EndLine: lineNum, EndCol: valueEnd},
+	}
+}
+
+// valueEnd is the end of the value starting at start, without a trailing
+// comment or whitespace. A # starts a comment unless it's escaped or inside a
+// quoted string or a template.
+func valueEnd(line string, start int) int {
+	end := len(line)
+	var quote byte
+loop:
+	for i := start; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`':
+			quote = c
+		case strings.HasPrefix(line[i:], "{{"):
+			if j := strings.Index(line[i:], "}}"); j >= 0 {
+				i += j + 1
+			}
+		case c == '#':
+			end = i
+			break loop
+		}
+	}
+
+	return start + len(strings.TrimRightFunc(line[start:end], isSpace))
+}
+
 func splitHeader(line string) (string, string) {
 	// split at first ':'
 	idx := strings.Index(line, ":")
@@ -1,7 +1,10 @@
 package hurlfile_test
 
 import (
+	"fmt"
+	"os"
 	"slices"
+	"strings"
 	"testing"
 
 	"github.com/ethancarlsson/hurl-lsp/expect"
@@ -120,3 +123,763 @@ func TestParse(t *testing.T) {
 		expect.ErrContains(t, "couldn't open file", err)
 	})
 }
+
+func TestOnReqSectionName(t *testing.T) {
+	tests := []struct {
+		text      string
+		line, col int
+		expected  bool
+	}{
+		{text: "GET http://a\n\nHTTP 200\n", line: 1, col: 0, expected: true},
+		{text: "GET http://a\n[\n", line: 1, col: 0, expected: true},
+		{text: "GET http://a\nAuthorization: x\n[\n", line: 2, col: 0, expected: true},
+		{text: "GET http://a\n[Query]\na: 1\n[\n", line: 3, col: 0, expected: true},
+		{text: "GET http://a\nAuthorization: x\n[Qu\nHTTP 200\n", line: 2, col: 2, expected: true},
+		{text: "GET http://a\nAuthorization: x\n\nHTTP 200\n", line: 2, col: 0, expected: true},
+		{text: "GET http://a\n[Query]\na: 1\n", line: 1, col: 3, expected: true},
+		// the request line
+		{text: "GET http://a\n\n", line: 0, col: 0, expected: false},
+		// a header
+		{text: "GET http://a\nAuthorization: x\n", line: 1, col: 0, expected: false},
+		// in the body
+		{text: "POST http://a\n{\n[\n}\n", line: 2, col: 0, expected: false},
+		{text: "POST http://a\n[1, 2]\n", line: 1, col: 0, expected: false},
+		// the response
+		{text: "GET http://a\nHTTP 200\n\n", line: 2, col: 0, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q %d:%d", tt.text, tt.line, tt.col), func(t *testing.T) {
+			hf, err := hurlfile.ParseText(tt.text)
+			expect.NoErr(t, err)
+
+			expect.Equals(t, tt.expected, hf.OnReqSectionName(tt.line, tt.col))
+		})
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		expected []hurlfile.Diagnostic
+	}{
+		{
+			name:     "valid file",
+			lines:    []string{"# comment", "POST {{url}}", "Accept: */*", "[Options]", "insecure: true", "`hello`", "HTTP 200", "[Asserts]", `jsonpath "$.id" == 1`},
+			expected: nil,
+		},
+		{
+			name:  "unknown section",
+			lines: []string{"GET /", "HTTP 200", "  [Cap]"},
+			expected: []hurlfile.Diagnostic{
+				{Range: hurlfile.SourceRange{StartLine: 2, StartCol: 2, EndLine: 2, EndCol: 7}, Severity: hurlfile.SeverityError, Message: "unknown section [Cap]"},
+			},
+		},
+		{
+			name:  "response section in request",
+			lines: []string{"GET /", "[Captures]", "id: jsonpath \"$.id\""},
+			expected: []hurlfile.Diagnostic{
+				{Range: hurlfile.SourceRange{StartLine: 1, StartCol: 0, EndLine: 1, EndCol: 10}, Severity: hurlfile.SeverityError, Message: `[Captures] is a response section, it must come after a response status line like "HTTP 200"`},
+			},
+		},
+		{
+			name:  "stray lines",
+			lines: []string{"Accept: */*", "get /", "GET /"},
+			expected: []hurlfile.Diagnostic{
+				{Range: hurlfile.SourceRange{StartLine: 0, StartCol: 0, EndLine: 0, EndCol: 11}, Severity: hurlfile.SeverityError, Message: `unexpected line outside of an entry, expected a request line like "GET https://example.org"`},
+				{Range: hurlfile.SourceRange{StartLine: 1, StartCol: 0, EndLine: 1, EndCol: 5}, Severity: hurlfile.SeverityError, Message: `unexpected line outside of an entry, expected a request line like "GET https://example.org"`},
+			},
+		},
+		{
+			name:  "invalid status codes",
+			lines: []string{"GET /", "HTTP 20", "GET /", "HTTP/4 abc", "GET /", "HTTP", "GET /", "HTTP *"},
+			expected: []hurlfile.Diagnostic{
+				{Range: hurlfile.SourceRange{StartLine: 1, StartCol: 5, EndLine: 1, EndCol: 7}, Severity: hurlfile.SeverityError, Message: `invalid status code "20", expected a number between 100 and 599 or *`},
+				{Range: hurlfile.SourceRange{StartLine: 3, StartCol: 0, EndLine: 3, EndCol: 6}, Severity: hurlfile.SeverityError, Message: `invalid HTTP version "HTTP/4", expected HTTP, HTTP/1.0, HTTP/1.1, HTTP/2 or HTTP/3`},
+				{Range: hurlfile.SourceRange{StartLine: 3, StartCol: 7, EndLine: 3, EndCol: 10}, Severity: hurlfile.SeverityError, Message: `invalid status code "abc", expected a number between 100 and 599 or *`},
+				{Range: hurlfile.SourceRange{StartLine: 5, StartCol: 0, EndLine: 5, EndCol: 4}, Severity: hurlfile.SeverityError, Message: `missing status code, expected a status like "HTTP 200" or "HTTP *"`},
+			},
+		},
+		{
+			name:  "malformed headers",
+			lines: []string{"GET /", "Authorization Bearer abc", "Content Type: json", "HTTP 200", "Location"},
+			expected: []hurlfile.Diagnostic{
+				{Range: hurlfile.SourceRange{StartLine: 1, StartCol: 0, EndLine: 1, EndCol: 24}, Severity: hurlfile.SeverityError, Message: `malformed header "Authorization Bearer abc", expected "name: value"`},
+				{Range: hurlfile.SourceRange{StartLine: 2, StartCol: 0, EndLine: 2, EndCol: 12}, Severity: hurlfile.SeverityError, Message: `invalid header name "Content Type"`},
+				{Range: hurlfile.SourceRange{StartLine: 4, StartCol: 0, EndLine: 4, EndCol: 8}, Severity: hurlfile.SeverityError, Message: `malformed header "Location", expected "name: value"`},
+			},
+		},
+		{
+			name:  "unterminated bodies",
+			lines: []string{"POST /", "base64,aGVsbG8=", "POST /", "`hello", "POST /", "```json", `{"a": 1}`, "GET /"},
+			expected: []hurlfile.Diagnostic{
+				{Range: hurlfile.SourceRange{StartLine: 1, StartCol: 0, EndLine: 1, EndCol: 15}, Severity: hurlfile.SeverityError, Message: "unterminated base64 body, expected a closing ;"},
+				{Range: hurlfile.SourceRange{StartLine: 3, StartCol: 0, EndLine: 3, EndCol: 6}, Severity: hurlfile.SeverityError, Message: "unterminated string body, expected a closing `"},
+				{Range: hurlfile.SourceRange{StartLine: 5, StartCol: 0, EndLine: 5, EndCol: 7}, Severity: hurlfile.SeverityError, Message: "unterminated multiline string body, expected a closing ```"},
+			},
+		},
+		{
+			name:  "key values in sections",
+			lines: []string{"GET /", "HTTP 200", "[Captures]", "id jsonpath \"$.id\""},
+			expected: []hurlfile.Diagnostic{
+				{Range: hurlfile.SourceRange{StartLine: 3, StartCol: 0, EndLine: 3, EndCol: 18}, Severity: hurlfile.SeverityError, Message: `expected "key: value" in [Captures] section`},
+			},
+		},
+		{
+			name: "malformed asserts",
+			lines: []string{
+				"GET /", "HTTP 200", "[Asserts]",
+				`count "$.list" == 2`,
+				`jsonpath == 1`,
+				`jsonpath "$.a" nth`,
+				`status`,
+				`status not`,
+				`status equals 200`,
+				`status ==`,
+				`status == 200 201`,
+			},
+			expected: []hurlfile.Diagnostic{
+				{Range: hurlfile.SourceRange{StartLine: 3, StartCol: 0, EndLine: 3, EndCol: 5}, Severity: hurlfile.SeverityError, Message: `unknown query "count"`},
+				{Range: hurlfile.SourceRange{StartLine: 4, StartCol: 0, EndLine: 4, EndCol: 8}, Severity: hurlfile.SeverityError, Message: `missing expression for the jsonpath query`},
+				{Range: hurlfile.SourceRange{StartLine: 5, StartCol: 15, EndLine: 5, EndCol: 18}, Severity: hurlfile.SeverityError, Message: `missing index for the nth filter`},
+				{Range: hurlfile.SourceRange{StartLine: 5, StartCol: 15, EndLine: 5, EndCol: 18}, Severity: hurlfile.SeverityError, Message: `missing predicate, expected a predicate like == or exists after "nth"`},
+				{Range: hurlfile.SourceRange{StartLine: 6, StartCol: 0, EndLine: 6, EndCol: 6}, Severity: hurlfile.SeverityError, Message: `missing predicate, expected a predicate like == or exists after "status"`},
+				{Range: hurlfile.SourceRange{StartLine: 7, StartCol: 7, EndLine: 7, EndCol: 10}, Severity: hurlfile.SeverityError, Message: `missing predicate after not`},
+				{Range: hurlfile.SourceRange{StartLine: 8, StartCol: 7, EndLine: 8, EndCol: 13}, Severity: hurlfile.SeverityError, Message: `unknown predicate "equals"`},
+				{Range: hurlfile.SourceRange{StartLine: 9, StartCol: 7, EndLine: 9, EndCol: 9}, Severity: hurlfile.SeverityError, Message: `missing value for the == predicate`},
+				{Range: hurlfile.SourceRange{StartLine: 10, StartCol: 14, EndLine: 10, EndCol: 17}, Severity: hurlfile.SeverityError, Message: `unexpected text after the == predicate`},
+			},
+		},
+		{
+			name:  "malformed request sections",
+			lines: []string{"POST /", "[Multipart]", "photo: file,rex.png", "[BasicAuth]", "bob: secret", "alice: secret"},
+			expected: []hurlfile.Diagnostic{
+				{Range: hurlfile.SourceRange{StartLine: 2, StartCol: 7, EndLine: 2, EndCol: 19}, Severity: hurlfile.SeverityError, Message: `unterminated file parameter, expected a closing ; e.g. file,data.txt;`},
+				{Range: hurlfile.SourceRange{StartLine: 5, StartCol: 0, EndLine: 5, EndCol: 13}, Severity: hurlfile.SeverityError, Message: `[BasicAuth] must have a single "user: password" line`},
+			},
+		},
+		{
+			name: "invalid options",
+			lines: []string{
+				"GET /", "[Options]",
+				"insecur: true",
+				"insecure: yes",
+				"retry: often",
+				"delay: 2 seconds",
+				"variable: id",
+				"location:",
+				"retry: {{retries}}",
+				"max-redirs: -1",
+				"retry-interval: 500",
+			},
+			expected: []hurlfile.Diagnostic{
+				{Range: hurlfile.SourceRange{StartLine: 2, StartCol: 0, EndLine: 2, EndCol: 7}, Severity: hurlfile.SeverityError, Message: `unknown option "insecur"`},
+				{Range: hurlfile.SourceRange{StartLine: 3, StartCol: 10, EndLine: 3, EndCol: 13}, Severity: hurlfile.SeverityError, Message: `the insecure option expects a boolean, true or false`},
+				{Range: hurlfile.SourceRange{StartLine: 4, StartCol: 7, EndLine: 4, EndCol: 12}, Severity: hurlfile.SeverityError, Message: `the retry option expects an integer`},
+				{Range: hurlfile.SourceRange{StartLine: 5, StartCol: 7, EndLine: 5, EndCol: 16}, Severity: hurlfile.SeverityError, Message: `the delay option expects a duration like 500ms, 2s or 1m`},
+				{Range: hurlfile.SourceRange{StartLine: 6, StartCol: 10, EndLine: 6, EndCol: 12}, Severity: hurlfile.SeverityError, Message: `the variable option expects name=value`},
+				{Range: hurlfile.SourceRange{StartLine: 7, StartCol: 0, EndLine: 7, EndCol: 9}, Severity: hurlfile.SeverityError, Message: `missing value for the location option`},
+			},
+		},
+		{
+			name: "malformed captures",
+			lines: []string{
+				"GET /", "HTTP 200", "[Captures]",
+				`id:`,
+				`id: jsonpath "$.id" == 1`,
+				`name: jsonpth "$.name"`,
+				`token: header "X-Token" redact`,
+			},
+			expected: []hurlfile.Diagnostic{
+				{Range: hurlfile.SourceRange{StartLine: 3, StartCol: 0, EndLine: 3, EndCol: 3}, Severity: hurlfile.SeverityError, Message: `missing query for the id capture, expected a query like jsonpath "$.id"`},
+				{Range: hurlfile.SourceRange{StartLine: 4, StartCol: 20, EndLine: 4, EndCol: 24}, Severity: hurlfile.SeverityError, Message: `unexpected text after the id capture query, expected filters`},
+				{Range: hurlfile.SourceRange{StartLine: 5, StartCol: 6, EndLine: 5, EndCol: 13}, Severity: hurlfile.SeverityError, Message: `unknown query "jsonpth"`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hf, err := hurlfile.Parse(tt.lines)
+			expect.NoErr(t, err)
+			expect.Equals(t, tt.expected, hf.Diagnostics)
+		})
+	}
+}
+
+func TestUndefinedTemplates(t *testing.T) {
+	lines := []string{
+		"GET {{url}}/pets/{{id}}", // 0
+		"HTTP 200",                // 1
+		"[Captures]",              // 2
+		"id: jsonpath \"$.id\"",   // 3
+		"[Asserts]",               // 4
+		"jsonpath \"$.id\" == {{id}}",
+		"POST {{ url }}/pets/{{id}}", // 6
+		"X-Request-Id: {{newUuid}}",
+		"[Options]",
+		"variable: name=rex",
+		"{\"name\": \"{{name}}\", \"tag\": \"{{tag}}\"}",
+	}
+
+	hf, err := hurlfile.Parse(lines)
+	expect.NoErr(t, err)
+
+	expect.Equals(t, 8, len(hf.Templates))
+	expect.Equals(t, []hurlfile.Ranged[string]{
+		{Value: "id", Range: hurlfile.SourceRange{StartLine: 0, StartCol: 19, EndLine: 0, EndCol: 21}},
+		{Value: "tag", Range: hurlfile.SourceRange{StartLine: 10, StartCol: 31, EndLine: 10, EndCol: 34}},
+	}, hf.UndefinedTemplates([]string{"url"}))
+
+	expect.Equals(t, "url", hf.UndefinedTemplates(nil)[0].Value)
+	expect.Equals(t, hurlfile.SourceRange{StartLine: 6, StartCol: 8, EndLine: 6, EndCol: 11}, hf.UndefinedTemplates(nil)[2].Range)
+}
+
+func TestDefinitionOf(t *testing.T) {
+	lines := []string{
+		"GET /pets",               // 0
+		"HTTP 200",                // 1
+		"[Captures]",              // 2
+		"  id: jsonpath \"$.id\"", // 3
+		"GET /pets/{{id}}",        // 4
+		"HTTP 200",                // 5
+		"[Captures]",              // 6
+		"name: jsonpath \"$.name\"",
+		"id: jsonpath \"$.id\"", // 8
+		"GET /pets/{{id}}/{{name}}/{{tag}}",
+		"HTTP 200",
+		"[Captures]",
+		"tag: jsonpath \"$.tag\"",
+	}
+
+	hf, err := hurlfile.Parse(lines)
+	expect.NoErr(t, err)
+
+	tmpl, ok := hf.TemplateAt(4, 12)
+	expect.Equals(t, true, ok)
+	def, ok := hf.DefinitionOf(tmpl)
+	expect.Equals(t, true, ok)
+	expect.Equals(t, hurlfile.Ranged[string]{Value: "id", Range: hurlfile.SourceRange{StartLine: 3, StartCol: 2, EndLine: 3, EndCol: 4}}, def)
+
+	// nearest preceding capture
+	tmpl, _ = hf.TemplateAt(9, 12)
+	def, _ = hf.DefinitionOf(tmpl)
+	expect.Equals(t, hurlfile.SourceRange{StartLine: 8, StartCol: 0, EndLine: 8, EndCol: 2}, def.Range)
+
+	tmpl, _ = hf.TemplateAt(9, 20)
+	def, _ = hf.DefinitionOf(tmpl)
+	expect.Equals(t, hurlfile.SourceRange{StartLine: 7, StartCol: 0, EndLine: 7, EndCol: 4}, def.Range)
+
+	// captured later in the file
+	tmpl, ok = hf.TemplateAt(9, 28)
+	expect.Equals(t, true, ok)
+	_, ok = hf.DefinitionOf(tmpl)
+	expect.Equals(t, false, ok)
+
+	_, ok = hf.TemplateAt(9, 2)
+	expect.Equals(t, false, ok)
+}
+
+func TestTokens(t *testing.T) {
+	lines := []string{
+		"# create",                  // 0
+		"POST {{url}}/pets",         // 1
+		"Accept: application/json",  // 2
+		"[Options]",                 // 3
+		"retry: 3",                  // 4
+		`{"name": "{{name}}"}`,      // 5
+		"HTTP/1.1 201",              // 6
+		"[Captures]",                // 7
+		`id: jsonpath "$.id" toInt`, // 8
+		"[Asserts]",                 // 9
+		`header "Location" not contains "/pets/{{id}}"`,
+		`body matches /\d+/`,
+	}
+	hf, err := hurlfile.Parse(lines)
+	expect.NoErr(t, err)
+
+	type tok struct {
+		kind hurlfile.TokenKind
+		text string
+	}
+	actual := make([]tok, 0, len(hf.Tokens))
+	for _, token := range hf.Tokens {
+		r := token.Range
+		actual = append(actual, tok{token.Kind, lines[r.StartLine][r.StartCol:r.EndCol]})
+	}
+
+	expect.Equals(t, []tok{
+		{hurlfile.TokenComment, "# create"},
+		{hurlfile.TokenMethod, "POST"},
+		{hurlfile.TokenTemplate, "{{url}}"},
+		{hurlfile.TokenURL, "/pets"},
+		{hurlfile.TokenKey, "Accept"},
+		{hurlfile.TokenValue, "application/json"},
+		{hurlfile.TokenSectionName, "[Options]"},
+		{hurlfile.TokenKey, "retry"},
+		{hurlfile.TokenNumber, "3"},
+		{hurlfile.TokenBody, `{"name": "`},
+		{hurlfile.TokenTemplate, "{{name}}"},
+		{hurlfile.TokenBody, `"}`},
+		{hurlfile.TokenVersion, "HTTP/1.1"},
+		{hurlfile.TokenStatus, "201"},
+		{hurlfile.TokenSectionName, "[Captures]"},
+		{hurlfile.TokenVariable, "id"},
+		{hurlfile.TokenQuery, "jsonpath"},
+		{hurlfile.TokenString, `"$.id"`},
+		{hurlfile.TokenFilter, "toInt"},
+		{hurlfile.TokenSectionName, "[Asserts]"},
+		{hurlfile.TokenQuery, "header"},
+		{hurlfile.TokenString, `"Location"`},
+		{hurlfile.TokenPredicate, "not"},
+		{hurlfile.TokenPredicate, "contains"},
+		{hurlfile.TokenString, `"/pets/`},
+		{hurlfile.TokenTemplate, "{{id}}"},
+		{hurlfile.TokenString, `"`},
+		{hurlfile.TokenQuery, "body"},
+		{hurlfile.TokenPredicate, "matches"},
+		{hurlfile.TokenRegex, `/\d+/`},
+	}, actual)
+}
+
+func TestSyntax(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		for _, fixture := range []string{"test.hurl", "test_captures.hurl", "test_partial_req.hurl"} {
+			text, err := os.ReadFile("../fixtures/" + fixture)
+			expect.NoErr(t, err)
+
+			hf, err := hurlfile.ParseText(string(text))
+			expect.NoErr(t, err)
+			expect.Equals(t, string(text), hf.Syntax.String())
+		}
+
+		texts := []string{
+			"",
+			"GET /\r\nHTTP 200\r\n",
+			"\t GET  {{url}}/a # get it\n\n\n  # between\nHTTP 200 extra\n[Cap]\n???\n",
+			"stray\nPOST /\nX-A:1 #one\nX-A : 2\n```\nunterminated",
+			"GET /\nHTTP 200\n[Asserts]\njsonpath \"$.a#b\" == 1 # trailing\r",
+			"POST http://a\n{\n\t\n}\n",
+			"POST http://a\n```\n\t\n```\n",
+			"POST http://a\n{\n  \r\n}\r\n",
+		}
+		for _, text := range texts {
+			hf, err := hurlfile.ParseText(text)
+			expect.NoErr(t, err)
+			expect.Equals(t, text, hf.Syntax.String())
+
+			// every leaf has the range of its text
+			lines := strings.Split(text, "\n")
+			for _, leaf := range hf.Syntax.Leaves() {
+				r := leaf.Range
+				if leaf.Token.Kind == hurlfile.TokenNewline {
+					expect.Equals(t, r.StartLine+1, r.EndLine)
+					continue
+				}
+				expect.Equals(t, leaf.Text, lines[r.StartLine][r.StartCol:r.EndCol])
+			}
+		}
+	})
+
+	t.Run("tree", func(t *testing.T) {
+		text := strings.Join([]string{
+			"# first", // 0
+			"POST /pets",
+			"X-A: 1 # one",
+			"X-A: 2",
+			"[Options]", // 4
+			"retry: 3",
+			`{"name": "rex"}`, // 6
+			"",
+			"HTTP 201", // 8
+			"[Captures]",
+			"id: jsonpath \"$.id\"",
+			"",
+		}, "\n")
+		hf, err := hurlfile.ParseText(text)
+		expect.NoErr(t, err)
+
+		type node struct {
+			kind       hurlfile.NodeKind
+			start, end int
+		}
+		var flatten func(n *hurlfile.Node) []node
+		flatten = func(n *hurlfile.Node) []node {
+			if n.Kind == hurlfile.NodeLine {
+				return nil
+			}
+			nodes := []node{{n.Kind, n.Range.StartLine, n.Range.EndLine}}
+			for _, c := range n.Children {
+				nodes = append(nodes, flatten(c)...)
+			}
+			return nodes
+		}
+
+		// ranges include the line endings, so most end at the start of the next line
+		expect.Equals(t, []node{
+			{hurlfile.NodeFile, 0, 11},
+			{hurlfile.NodeEntry, 1, 11},
+			{hurlfile.NodeRequest, 1, 8},
+			{hurlfile.NodeSection, 4, 6},
+			{hurlfile.NodeBody, 6, 7},
+			{hurlfile.NodeResponse, 8, 11},
+			{hurlfile.NodeSection, 9, 11},
+		}, flatten(hf.Syntax))
+
+		headers := hf.Entries[0].Request.Headers.Value
+		expect.Equals(t, 2, len(headers))
+		expect.Equals(t, "1", headers[0].Value.Value)
+		expect.Equals(t, hurlfile.SourceRange{StartLine: 2, StartCol: 5, EndLine: 2, EndCol: 6}, headers[0].Value.Range)
+		expect.Equals(t, "2", headers[1].Value.Value)
+
+		expect.Equals(t, []hurlfile.Ranged[string]{
+			{Value: "# first", Range: hurlfile.SourceRange{StartLine: 0, StartCol: 0, EndLine: 0, EndCol: 7}},
+			{Value: "# one", Range: hurlfile.SourceRange{StartLine: 2, StartCol: 7, EndLine: 2, EndCol: 12}},
+		}, hf.Comments)
+
+		pairs := hf.Entries[0].Response.Sections[0].Pairs
+		expect.Equals(t, 1, len(pairs))
+		expect.Equals(t, "id", pairs[0].Key.Value)
+		expect.Equals(t, `jsonpath "$.id"`, pairs[0].Value.Value)
+	})
+}
+
+func TestAsserts(t *testing.T) {
+	lines := []string{
+		"GET /",
+		"HTTP 200",
+		"[Asserts]",
+		`header "Location" not contains "/pets/{{id}}"`, // 3
+		`jsonpath "$.tags" nth 0 replace "a" "b" == null # comment`,
+		`status < 300`, // 5
+		`body isEmpty`,
+	}
+	hf, err := hurlfile.Parse(lines)
+	expect.NoErr(t, err)
+	expect.Equals(t, 0, len(hf.Diagnostics))
+
+	rng := func(line, start, end int) hurlfile.SourceRange {
+		return hurlfile.SourceRange{StartLine: line, StartCol: start, EndLine: line, EndCol: end}
+	}
+	asserts := hf.Entries[0].Response.Sections[0].Asserts
+	expect.Equals(t, []hurlfile.Assertion{
+		{
+			Query: hurlfile.Query{
+				Name:  hurlfile.Ranged[string]{Value: "header", Range: rng(3, 0, 6)},
+				Arg:   &hurlfile.Value{Kind: hurlfile.ValueString, Text: `"Location"`, Range: rng(3, 7, 17)},
+				Range: rng(3, 0, 17),
+			},
+			Filters: []hurlfile.Filter{},
+			Predicate: &hurlfile.Predicate{
+				Not:   &hurlfile.SourceRange{StartLine: 3, StartCol: 18, EndLine: 3, EndCol: 21},
+				Name:  hurlfile.Ranged[string]{Value: "contains", Range: rng(3, 22, 30)},
+				Value: &hurlfile.Value{Kind: hurlfile.ValueString, Text: `"/pets/{{id}}"`, Range: rng(3, 31, 45)},
+				Range: rng(3, 18, 45),
+			},
+			Range: rng(3, 0, 45),
+		},
+		{
+			Query: hurlfile.Query{
+				Name:  hurlfile.Ranged[string]{Value: "jsonpath", Range: rng(4, 0, 8)},
+				Arg:   &hurlfile.Value{Kind: hurlfile.ValueString, Text: `"$.tags"`, Range: rng(4, 9, 17)},
+				Range: rng(4, 0, 17),
+			},
+			Filters: []hurlfile.Filter{
+				{
+					Name:  hurlfile.Ranged[string]{Value: "nth", Range: rng(4, 18, 21)},
+					Args:  []hurlfile.Value{{Kind: hurlfile.ValueNumber, Text: "0", Range: rng(4, 22, 23)}},
+					Range: rng(4, 18, 23),
+				},
+				{
+					Name: hurlfile.Ranged[string]{Value: "replace", Range: rng(4, 24, 31)},
+					Args: []hurlfile.Value{
+						{Kind: hurlfile.ValueString, Text: `"a"`, Range: rng(4, 32, 35)},
+						{Kind: hurlfile.ValueString, Text: `"b"`, Range: rng(4, 36, 39)},
+					},
+					Range: rng(4, 24, 39),
+				},
+			},
+			Predicate: &hurlfile.Predicate{
+				Name:  hurlfile.Ranged[string]{Value: "==", Range: rng(4, 40, 42)},
+				Value: &hurlfile.Value{Kind: hurlfile.ValueNull, Text: "null", Range: rng(4, 43, 47)},
+				Range: rng(4, 40, 47),
+			},
+			Range: rng(4, 0, 47),
+		},
+		{
+			Query: hurlfile.Query{
+				Name:  hurlfile.Ranged[string]{Value: "status", Range: rng(5, 0, 6)},
+				Range: rng(5, 0, 6),
+			},
+			Filters: []hurlfile.Filter{},
+			Predicate: &hurlfile.Predicate{
+				Name:  hurlfile.Ranged[string]{Value: "<", Range: rng(5, 7, 8)},
+				Value: &hurlfile.Value{Kind: hurlfile.ValueNumber, Text: "300", Range: rng(5, 9, 12)},
+				Range: rng(5, 7, 12),
+			},
+			Range: rng(5, 0, 12),
+		},
+		{
+			Query: hurlfile.Query{
+				Name:  hurlfile.Ranged[string]{Value: "body", Range: rng(6, 0, 4)},
+				Range: rng(6, 0, 4),
+			},
+			Filters: []hurlfile.Filter{},
+			Predicate: &hurlfile.Predicate{
+				Name:  hurlfile.Ranged[string]{Value: "isEmpty", Range: rng(6, 5, 12)},
+				Range: rng(6, 5, 12),
+			},
+			Range: rng(6, 0, 12),
+		},
+	}, asserts)
+}
+
+func TestCaptures(t *testing.T) {
+	lines := []string{
+		"GET /pets",
+		"HTTP 200",
+		"[Captures]",
+		`id: jsonpath "$[0].id"`, // 3
+		`ids: jsonpath "$[*].id"`,
+		`count: jsonpath "$..id" count`, // 5
+		`first: jsonpath "$.ids" nth 0`,
+		`location: header "Location" redact`, // 7
+		`code: status`,
+		`names: body split ","`, // 9
+	}
+	hf, err := hurlfile.Parse(lines)
+	expect.NoErr(t, err)
+	expect.Equals(t, 0, len(hf.Diagnostics))
+
+	captures := hf.Entries[0].Response.Sections[0].Captures
+	expect.Equals(t, 7, len(captures))
+
+	id := captures[0]
+	expect.Equals(t, hurlfile.Ranged[string]{Value: "id", Range: hurlfile.SourceRange{StartLine: 3, StartCol: 0, EndLine: 3, EndCol: 2}}, id.Name)
+	expect.Equals(t, "jsonpath", id.Query.Name.Value)
+	expect.Equals(t, &hurlfile.Value{
+		Kind:  hurlfile.ValueString,
+		Text:  `"$[0].id"`,
+		Range: hurlfile.SourceRange{StartLine: 3, StartCol: 13, EndLine: 3, EndCol: 22},
+	}, id.Query.Arg)
+	expect.Equals(t, false, id.Redact)
+
+	expect.Equals(t, "nth", captures[3].Filters[0].Name.Value)
+	expect.Equals(t, "0", captures[3].Filters[0].Args[0].Text)
+	expect.Equals(t, true, captures[4].Redact)
+
+	types := make([]string, 0, len(captures))
+	for _, c := range captures {
+		types = append(types, c.Type())
+	}
+	expect.Equals(t, []string{"any", "collection", "number", "any", "string", "number", "collection"}, types)
+
+	capture, ok := hf.CaptureOf(hurlfile.Ranged[string]{Value: "ids", Range: hurlfile.SourceRange{StartLine: 11}})
+	expect.Equals(t, true, ok)
+	expect.Equals(t, captures[1], capture)
+
+	_, ok = hf.CaptureOf(hurlfile.Ranged[string]{Value: "nope", Range: hurlfile.SourceRange{StartLine: 11}})
+	expect.Equals(t, false, ok)
+}
+
+func TestRequestSections(t *testing.T) {
+	lines := []string{
+		"POST /pets", // 0
+		"[Query]",
+		"status: available",
+		"status: sold", // 3
+		"[Form]",
+		"name: rex",
+		"[Multipart]", // 6
+		"photo: file,rex.png; image/png",
+		"notes: file,notes.txt;",
+		"tag: dog", // 9
+		"[Cookies]",
+		"theme: dark",
+		"[BasicAuth]", // 12
+		"bob: s3cr:et",
+		"[Options]",
+		"insecure: true", // 15
+		"retry: 3",
+		"delay: 100ms",
+		"variable: id={{id}}", // 18
+		"proxy: {{proxy}}",
+	}
+	hf, err := hurlfile.Parse(lines)
+	expect.NoErr(t, err)
+	expect.Equals(t, 0, len(hf.Diagnostics))
+
+	rng := func(line, start, end int) hurlfile.SourceRange {
+		return hurlfile.SourceRange{StartLine: line, StartCol: start, EndLine: line, EndCol: end}
+	}
+	typed := hf.Entries[0].Request.Typed
+
+	expect.Equals(t, 2, len(typed.QueryParams))
+	expect.Equals(t, "status", typed.QueryParams[1].Key.Value)
+	expect.Equals(t, "sold", typed.QueryParams[1].Value.Value)
+	expect.Equals(t, "rex", typed.FormParams[0].Value.Value)
+	expect.Equals(t, "dark", typed.Cookies[0].Value.Value)
+
+	expect.Equals(t, 3, len(typed.Multipart))
+	expect.Equals(t, &hurlfile.MultipartFile{
+		Path:        hurlfile.Ranged[string]{Value: "rex.png", Range: rng(7, 12, 19)},
+		ContentType: &hurlfile.Ranged[string]{Value: "image/png", Range: rng(7, 21, 30)},
+	}, typed.Multipart[0].File)
+	expect.Equals(t, &hurlfile.MultipartFile{
+		Path: hurlfile.Ranged[string]{Value: "notes.txt", Range: rng(8, 12, 21)},
+	}, typed.Multipart[1].File)
+	expect.Equals(t, (*hurlfile.MultipartFile)(nil), typed.Multipart[2].File)
+
+	expect.Equals(t, &hurlfile.Credentials{
+		User:     hurlfile.Ranged[string]{Value: "bob", Range: rng(13, 0, 3)},
+		Password: hurlfile.Ranged[string]{Value: "s3cr:et", Range: rng(13, 5, 12)},
+		Range:    rng(13, 0, 12),
+	}, typed.BasicAuth)
+
+	kinds := make([]hurlfile.OptionKind, 0, len(typed.Options))
+	for _, o := range typed.Options {
+		kinds = append(kinds, o.Value.Kind)
+	}
+	expect.Equals(t, []hurlfile.OptionKind{
+		hurlfile.OptionBool,
+		hurlfile.OptionInteger,
+		hurlfile.OptionDuration,
+		hurlfile.OptionString,
+		hurlfile.OptionTemplate,
+	}, kinds)
+
+	expect.Equals(t, hurlfile.QueryStringParams, hurlfile.CanonicalSection("Query"))
+	expect.Equals(t, hurlfile.Cookies, hurlfile.CanonicalSection("Cookies"))
+}
+
+func TestBodies(t *testing.T) {
+	rng := func(sl, sc, el, ec int) hurlfile.SourceRange {
+		return hurlfile.SourceRange{StartLine: sl, StartCol: sc, EndLine: el, EndCol: ec}
+	}
+
+	tests := []struct {
+		name     string
+		lines    []string
+		expected hurlfile.Body
+	}{
+		{
+			name:  "json",
+			lines: []string{"POST /", "{", `  "a": {{a}}`, "}"},
+			expected: hurlfile.Body{
+				Kind:    hurlfile.BodyJSON,
+				Content: hurlfile.Ranged[string]{Value: "{\n  \"a\": {{a}}\n}", Range: rng(1, 0, 3, 1)},
+				Range:   rng(1, 0, 3, 1),
+			},
+		},
+		{
+			name:  "json with a whitespace only line",
+			lines: []string{"POST /", "{", "\t", "}"},
+			expected: hurlfile.Body{
+				Kind:    hurlfile.BodyJSON,
+				Content: hurlfile.Ranged[string]{Value: "{\n\t\n}", Range: rng(1, 0, 3, 1)},
+				Range:   rng(1, 0, 3, 1),
+			},
+		},
+		{
+			name:  "multiline with a whitespace only line",
+			lines: []string{"POST /", "```", "\t", "```"},
+			expected: hurlfile.Body{
+				Kind:    hurlfile.BodyMultiline,
+				Lang:    hurlfile.Ranged[string]{Range: rng(1, 0, 1, 0)},
+				Content: hurlfile.Ranged[string]{Value: "\t", Range: rng(2, 0, 2, 1)},
+				Range:   rng(1, 0, 3, 3),
+			},
+		},
+		{
+			name:  "json scalar",
+			lines: []string{"POST /", "  42 "},
+			expected: hurlfile.Body{
+				Kind:    hurlfile.BodyJSON,
+				Content: hurlfile.Ranged[string]{Value: "42", Range: rng(1, 2, 1, 4)},
+				Range:   rng(1, 2, 1, 4),
+			},
+		},
+		{
+			name:  "xml",
+			lines: []string{"POST /", "<a>", "</a>"},
+			expected: hurlfile.Body{
+				Kind:    hurlfile.BodyXML,
+				Content: hurlfile.Ranged[string]{Value: "<a>\n</a>", Range: rng(1, 0, 2, 4)},
+				Range:   rng(1, 0, 2, 4),
+			},
+		},
+		{
+			name:  "multiline with a language hint",
+			lines: []string{"POST /", "```graphql", "{ pets { name } }", "```"},
+			expected: hurlfile.Body{
+				Kind:    hurlfile.BodyMultiline,
+				Lang:    hurlfile.Ranged[string]{Value: "graphql", Range: rng(1, 3, 1, 10)},
+				Content: hurlfile.Ranged[string]{Value: "{ pets { name } }", Range: rng(2, 0, 2, 17)},
+				Range:   rng(1, 0, 3, 3),
+			},
+		},
+		{
+			name:  "one line multiline",
+			lines: []string{"POST /", "```hello```"},
+			expected: hurlfile.Body{
+				Kind:    hurlfile.BodyMultiline,
+				Content: hurlfile.Ranged[string]{Value: "hello", Range: rng(1, 3, 1, 8)},
+				Range:   rng(1, 0, 1, 11),
+			},
+		},
+		{
+			name:  "string",
+			lines: []string{"POST /", "`hello`"},
+			expected: hurlfile.Body{
+				Kind:    hurlfile.BodyString,
+				Content: hurlfile.Ranged[string]{Value: "hello", Range: rng(1, 1, 1, 6)},
+				Range:   rng(1, 0, 1, 7),
+			},
+		},
+		{
+			name:  "base64",
+			lines: []string{"POST /", "base64, aGVsbG8= ;"},
+			expected: hurlfile.Body{
+				Kind:    hurlfile.BodyBase64,
+				Content: hurlfile.Ranged[string]{Value: "aGVsbG8=", Range: rng(1, 8, 1, 16)},
+				Range:   rng(1, 0, 1, 18),
+			},
+		},
+		{
+			name:  "hex",
+			lines: []string{"POST /", "hex,68656c6c6f;"},
+			expected: hurlfile.Body{
+				Kind:    hurlfile.BodyHex,
+				Content: hurlfile.Ranged[string]{Value: "68656c6c6f", Range: rng(1, 4, 1, 14)},
+				Range:   rng(1, 0, 1, 15),
+			},
+		},
+		{
+			name:  "file",
+			lines: []string{"POST /", "file,data.json;"},
+			expected: hurlfile.Body{
+				Kind:    hurlfile.BodyFile,
+				Content: hurlfile.Ranged[string]{Value: "data.json", Range: rng(1, 5, 1, 14)},
+				Range:   rng(1, 0, 1, 15),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hf, err := hurlfile.Parse(tt.lines)
+			expect.NoErr(t, err)
+			expect.Equals(t, &tt.expected, hf.Entries[0].Request.TypedBody)
+		})
+	}
+
+	t.Run("response", func(t *testing.T) {
+		hf, err := hurlfile.Parse([]string{"GET /", "HTTP 200", "[Asserts]", "status == 200", "```json", "[]", "```"})
+		expect.NoErr(t, err)
+		expect.Equals(t, (*hurlfile.Body)(nil), hf.Entries[0].Request.TypedBody)
+		expect.Equals(t, hurlfile.BodyMultiline, hf.Entries[0].Response.TypedBody.Kind)
+		expect.Equals(t, "json", hf.Entries[0].Response.TypedBody.Lang.Value)
+		expect.Equals(t, "[]", hf.Entries[0].Response.TypedBody.Content.Value)
+	})
+}
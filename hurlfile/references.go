@@ -0,0 +1,66 @@
+package hurlfile
+
+import (
+	"regexp"
+	"slices"
+)
+
+var reVariableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+func ValidVariableName(name string) bool {
+	return reVariableName.MatchString(name)
+}
+
+// VariableAt returns the variable under the cursor, either a {{template}} or
+// the key of a capture. Like TemplateAt, col is the LSP character.
+func (hf *HurlFile) VariableAt(line, col int) (Ranged[string], bool) {
+	if tmpl, ok := hf.TemplateAt(line, col); ok {
+		return tmpl, true
+	}
+
+	for _, capture := range hf.Captures() {
+		for _, def := range capture.Definitions {
+			if def.Range.StartLine == line && col >= def.Range.StartCol && col <= def.Range.EndCol {
+				return def, true
+			}
+		}
+	}
+
+	return Ranged[string]{}, false
+}
+
+// IsCaptured reports whether the variable is captured anywhere in the file
+func (hf *HurlFile) IsCaptured(name string) bool {
+	return slices.Contains(hf.Captures().Variables(), name)
+}
+
+// References returns every use of the variable, and its captures when
+// includeCaptures is true, in the order they appear in the file.
+func (hf *HurlFile) References(name string, includeCaptures bool) []Ranged[string] {
+	refs := make([]Ranged[string], 0)
+	if includeCaptures {
+		for _, capture := range hf.Captures() {
+			for _, def := range capture.Definitions {
+				if def.Value == name {
+					refs = append(refs, def)
+				}
+			}
+		}
+	}
+
+	for _, tmpl := range hf.Templates {
+		if tmpl.Value == name {
+			refs = append(refs, tmpl)
+		}
+	}
+
+	slices.SortStableFunc(refs, func(a, b Ranged[string]) int {
+		if a.Range.StartLine != b.Range.StartLine {
+			return a.Range.StartLine - b.Range.StartLine
+		}
+
+		return a.Range.StartCol - b.Range.StartCol
+	})
+
+	return refs
+}
@@ -18,10 +18,15 @@ type Target struct {
 type Request struct {
 	Method   Method
 	Target   Target
-	Headers  Ranged[map[string]string]
+	Headers  Ranged[[]KeyValue]
 	Sections []Section
-	Body     Ranged[[]string]
-	Range    SourceRange
+	// Typed are the sections with typed values, aliases are merged into
+	// the section they stand for
+	Typed RequestSections
+	Body  Ranged[[]string]
+	// TypedBody is nil when the request has no body
+	TypedBody *Body
+	Range     SourceRange
 }
 
 func (p *Parser) parseRequest() (*Request, error) {
@@ -34,15 +39,14 @@ func (p *Parser) parseRequest() (*Request, error) {
 		return nil, fmt.Errorf("expected method line but got empty")
 	}
 	method := parts[0]
-	target := ""
-	if len(parts) > 1 {
-		target = strings.TrimSpace(line[len(method):])
-	}
-
 	leadingWhitespace := countLeadingWhitespace(untrimmedLine)
+	targetStart := leadingWhitespace + len(method)
+	targetStart += countLeadingWhitespace(untrimmedLine[targetStart:])
+	target := untrimmedLine[targetStart:max(valueEnd(untrimmedLine, targetStart), targetStart)]
 
 	startLine := p.i - 1
-	headers := make(map[string]string)
+	p.recordTemplates(untrimmedLine, startLine)
+	p.tokenizeRequestLine(untrimmedLine, startLine, method)
 	req := &Request{
 		Method: Method{
 			Name: strings.TrimSpace(method),
@@ -57,23 +61,21 @@ func (p *Parser) parseRequest() (*Request, error) {
 			Target: target,
 			Range: SourceRange{
 				StartLine: startLine,
-				// Could be more than +1, but we can consider all whitespace after the first to be part of the target
-				StartCol: leadingWhitespace + len(method) + 1,
-				EndCol:   len(untrimmedLine),
-				EndLine:  startLine,
+				StartCol:  targetStart,
+				EndCol:    targetStart + len(target),
+				EndLine:   startLine,
 			},
 		},
-		Headers: Ranged[map[string]string]{
-			Value: headers,
-		},
 		Range: computeLineRange(line, startLine),
 	}
 
 	defer func() {
 		req.Range.EndLine = p.i - 1
 		req.Range.EndCol = len(p.peek())
-		req.Body.Range.EndLine = p.i - 1
-		req.Body.Range.EndCol = len(p.peek())
+		if len(req.Body.Value) == 0 {
+			req.Body.Range.EndLine = p.i - 1
+			req.Body.Range.EndCol = len(p.peek())
+		}
 	}()
 
 	for !p.eof() {
@@ -93,26 +95,12 @@ func (p *Parser) parseRequest() (*Request, error) {
 
 		// If section start
 		if matches := reSectionLine.FindStringSubmatch(raw); matches != nil {
-			sec, err := p.parseSection()
+			sec, err := p.parseSection(false)
 			if err != nil {
 				return nil, err
 			}
 			req.Sections = append(req.Sections, *sec)
-			continue
-		}
-
-		// Header line?
-		if reHeaderLine.MatchString(raw) {
-			// It should never be zero unless there are no headers
-			if req.Headers.Range.StartLine == 0 {
-				req.Headers.Range.StartLine = p.i
-				req.Headers.Range.EndLine = p.i
-			}
-
-			p.i++
-			k, v := splitHeader(raw)
-			req.Headers.Value[k] = v
-			req.Headers.Range.EndCol = len(raw) - 1
+			p.typeRequestSection(&req.Typed, sec)
 			continue
 		}
 
@@ -122,14 +110,30 @@ func (p *Parser) parseRequest() (*Request, error) {
 			continue
 		}
 
-		req.Body.Value = append(req.Body.Value, raw)
-		if req.Body.Range.StartLine == 0 {
+		if isBodyStart(trim) {
 			req.Body.Range.StartLine = p.i
 			req.Body.Range.StartCol = 0
+			req.Body.Value, req.TypedBody = p.parseBody()
+			last := req.Body.Value[len(req.Body.Value)-1]
+			req.Body.Range.EndCol = len(last) - 1
+			req.Body.Range.EndLine = req.Body.Range.StartLine + len(req.Body.Value) - 1
+			break
+		}
+
+		// Header line?
+		if reHeaderLine.MatchString(trim) {
+			// It should never be zero unless there are no headers
+			if req.Headers.Range.StartLine == 0 {
+				req.Headers.Range.StartLine = p.i
+			}
+			req.Headers.Range.EndLine = p.i
+
+			req.Headers.Value = append(req.Headers.Value, p.parseHeader(raw))
+			req.Headers.Range.EndCol = len(raw) - 1
+			continue
 		}
 
-		req.Body.Range.EndCol = len(raw) - 1
-		req.Body.Range.EndLine = p.i - 1
+		p.errorf(trimmedRange(raw, p.i), "malformed header %q, expected \"name: value\"", trim)
 		p.i++
 	}
 
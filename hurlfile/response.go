@@ -1,7 +1,6 @@
 package hurlfile
 
 import (
-	"fmt"
 	"strconv"
 	"strings"
 )
@@ -9,31 +8,67 @@ import (
 type Response struct {
 	Version  string
 	Status   int
-	Headers  map[string]string
+	Headers  []KeyValue
 	Sections []Section
-	Body     string
-	Range    SourceRange
+	Body     Ranged[string]
+	// TypedBody is nil when the response has no body
+	TypedBody *Body
+	Range     SourceRange
+}
+
+var httpVersions = map[string]bool{
+	"HTTP":     true,
+	"HTTP/1.0": true,
+	"HTTP/1.1": true,
+	"HTTP/2":   true,
+	"HTTP/3":   true,
 }
 
 // parseResponse expects current line is response line (HTTP/.. status)
-func (p *Parser) parseResponse() (*Response, error) {
-	line := strings.TrimSpace(p.next())
+func (p *Parser) parseResponse() *Response {
+	raw := p.next()
+	line := strings.TrimSpace(raw)
+	lineNum := p.i - 1
 	parts := strings.Fields(line)
-	if len(parts) < 2 {
-		return nil, fmt.Errorf("invalid response status line: %q", line)
-	}
+	p.tokenizeResponseLine(raw, lineNum)
 	version := parts[0]
-	statusNum, err := strconv.Atoi(parts[1])
-	if err != nil {
-		statusNum = 0
+	versionStart := countLeadingWhitespace(raw)
+	if !httpVersions[version] {
+		p.errorf(
+			SourceRange{StartLine: lineNum, StartCol: versionStart, EndLine: lineNum, EndCol: versionStart + len(version)},
+			"invalid HTTP version %q, expected HTTP, HTTP/1.0, HTTP/1.1, HTTP/2 or HTTP/3", version,
+		)
+	}
+
+	statusNum := 0
+	if len(parts) < 2 {
+		p.errorf(trimmedRange(raw, lineNum), "missing status code, expected a status like \"HTTP 200\" or \"HTTP *\"")
+	} else {
+		statusStart := strings.Index(raw[versionStart+len(version):], parts[1]) + versionStart + len(version)
+		statusRange := SourceRange{StartLine: lineNum, StartCol: statusStart, EndLine: lineNum, EndCol: statusStart + len(parts[1])}
+		status, err := strconv.Atoi(parts[1])
+		switch {
+		case parts[1] == "*":
+		case err != nil || len(parts[1]) != 3 || status < 100 || status > 599:
+			p.errorf(statusRange, "invalid status code %q, expected a number between 100 and 599 or *", parts[1])
+		default:
+			statusNum = status
+		}
+
+		if len(parts) > 2 {
+			extraStart := statusRange.EndCol + strings.Index(raw[statusRange.EndCol:], parts[2])
+			p.errorf(
+				SourceRange{StartLine: lineNum, StartCol: extraStart, EndLine: lineNum, EndCol: len(strings.TrimRightFunc(raw, isSpace))},
+				"unexpected text after the status code",
+			)
+		}
 	}
 
 	resp := &Response{
 		Version: version,
 		Status:  statusNum,
-		Headers: map[string]string{},
 		Range: SourceRange{
-			StartLine: p.i - 1,
+			StartLine: lineNum,
 			StartCol:  0,
 		},
 	}
@@ -48,94 +83,50 @@ func (p *Parser) parseResponse() (*Response, error) {
 		raw := p.peek()
 		trim := strings.TrimSpace(raw)
 		// If new request begins, stop
-		if reMethodLine.MatchString(trim) {
+		if reMethodLine.MatchString(trim) || reResponseLine.MatchString(trim) {
 			break
 		}
 		// If section start
 		if matches := reSectionLine.FindStringSubmatch(raw); matches != nil {
-			sec, err := p.parseSection()
+			sec, err := p.parseSection(true)
 			if err != nil {
-				return nil, err
+				return resp
 			}
 			resp.Sections = append(resp.Sections, *sec)
 			continue
 		}
 
-		// Header?
-		if reHeaderLine.MatchString(raw) {
-			p.i++
-			k, v := splitHeader(raw)
-			resp.Headers[k] = v
-			continue
-		}
-
-		// Empty line or comment - may be a separator before body
+		// Empty line or comment
 		if trim == "" || strings.HasPrefix(trim, "#") {
 			p.i++
-			// check next meaningful line
-			save := p.i
-			nextMeaningful := -1
-			for j := p.i; j < p.len; j++ {
-				t := strings.TrimSpace(p.lines[j])
-				if t == "" || strings.HasPrefix(t, "#") {
-					continue
-				}
-				nextMeaningful = j
-				break
-			}
-			if nextMeaningful == -1 {
-				p.i = save
-				continue
-			}
-			nextLine := strings.TrimSpace(p.lines[nextMeaningful])
-			if !(reHeaderLine.MatchString(nextLine) || reSectionLine.MatchString(nextLine) || reMethodLine.MatchString(nextLine)) {
-				// parse body as everything until next method
-				bodyLines := []string{}
-				for j := nextMeaningful; j < p.len; j++ {
-					t := strings.TrimSpace(p.lines[j])
-					if reMethodLine.MatchString(t) {
-						break
-					}
-					bodyLines = append(bodyLines, p.lines[j])
-					p.i = j + 1
-				}
-				resp.Body = strings.Join(bodyLines, "\n")
-				return resp, nil
-			}
 			continue
 		}
 
-		// If line looks like body start
-		trimL := strings.TrimLeft(raw, " \t")
-		if len(trimL) > 0 && (strings.HasPrefix(trimL, "{") || strings.HasPrefix(trimL, "[") || strings.HasPrefix(trimL, "`") || strings.HasPrefix(trimL, "```")) {
-			bodyLines := []string{}
-			for j := p.i; j < p.len; j++ {
-				t := strings.TrimSpace(p.lines[j])
-				if reMethodLine.MatchString(t) {
-					break
-				}
-				bodyLines = append(bodyLines, p.lines[j])
-				p.i = j + 1
+		if isBodyStart(trim) {
+			start := p.i
+			body, typed := p.parseBody()
+			resp.TypedBody = typed
+			resp.Body = Ranged[string]{
+				Value: strings.Join(body, "\n"),
+				Range: SourceRange{
+					StartLine: start,
+					StartCol:  0,
+					EndLine:   start + len(body) - 1,
+					EndCol:    len(body[len(body)-1]) - 1,
+				},
 			}
-			resp.Body = strings.Join(bodyLines, "\n")
-			return resp, nil
+			return resp
 		}
-		// fallback: treat rest as body
-		bodyLines := []string{}
-		for j := p.i; j < p.len; j++ {
-			t := strings.TrimSpace(p.lines[j])
-			if reMethodLine.MatchString(t) {
-				break
-			}
-			if reSectionLine.MatchString(p.lines[j]) {
-				break
-			}
-			bodyLines = append(bodyLines, p.lines[j])
-			p.i = j + 1
+
+		// Header?
+		if reHeaderLine.MatchString(trim) {
+			resp.Headers = append(resp.Headers, p.parseHeader(raw))
+			continue
 		}
-		resp.Body = strings.Join(bodyLines, "\n")
-		return resp, nil
+
+		p.errorf(trimmedRange(raw, p.i), "malformed header %q, expected \"name: value\"", trim)
+		p.i++
 	}
 
-	return resp, nil
+	return resp
 }
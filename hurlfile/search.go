@@ -1,5 +1,10 @@
 package hurlfile
 
+import (
+	"regexp"
+	"slices"
+)
+
 func (hf HurlFile) OnMethod(line, col int) bool {
 	// 3 is the length of the smallest method
 	if line == 0 && col <= 3 {
@@ -44,6 +49,38 @@ func (hf HurlFile) OnUri(line, col int) bool {
 	return false
 }
 
+// TemplateAt returns the {{template}} variable under the cursor. Unlike the
+// other lookups col is the LSP character, so the cursor can be just before or
+// just after the name.
+func (hf HurlFile) TemplateAt(line, col int) (Ranged[string], bool) {
+	for _, tmpl := range hf.Templates {
+		if tmpl.Range.StartLine == line && col >= tmpl.Range.StartCol && col <= tmpl.Range.EndCol {
+			return tmpl, true
+		}
+	}
+
+	return Ranged[string]{}, false
+}
+
+// SectionAt returns the request or response section that contains the line,
+// including the line with its name.
+func (hf HurlFile) SectionAt(line int) (Section, bool) {
+	for _, entry := range hf.Entries {
+		sections := entry.Request.Sections
+		if entry.Response != nil {
+			sections = append(slices.Clone(sections), entry.Response.Sections...)
+		}
+
+		for _, sec := range sections {
+			if line >= sec.Range.StartLine && line <= sec.Range.EndLine {
+				return sec, true
+			}
+		}
+	}
+
+	return Section{}, false
+}
+
 func (hf HurlFile) OnRespSectionName(line, col int) bool {
 	for _, entry := range hf.Entries {
 		if entry.Response == nil {
@@ -69,6 +106,69 @@ func (hf HurlFile) OnRespSectionName(line, col int) bool {
 	return false
 }
 
+// OnReqSectionName reports whether a request section can be started at the
+// cursor: at the start of a blank line or in the name of a section between
+// the request line and the body or response. A line with a section name
+// that is still being typed, like [ or [Que, is parsed as the start of a
+// body, so it counts too.
+func (hf HurlFile) OnReqSectionName(line, col int) bool {
+	for _, entry := range hf.Entries {
+		req := entry.Request
+		if line <= req.Range.StartLine || line > req.Range.EndLine {
+			continue
+		}
+
+		if len(req.Body.Value) > 0 && line >= req.Body.Range.StartLine {
+			return line == req.Body.Range.StartLine && rePartialSection.MatchString(req.Body.Value[0])
+		}
+
+		for _, s := range req.Sections {
+			if line != s.Range.StartLine {
+				continue
+			}
+
+			if col > s.Name.Range.StartCol-1 && col < s.Name.Range.EndCol+1 {
+				return true
+			}
+		}
+
+		return col <= 1 && !hf.hasTokens(line)
+	}
+
+	return false
+}
+
+// rePartialSection matches a section line without its closing bracket yet
+var rePartialSection = regexp.MustCompile(`^\s*\[[A-Za-z]*\s*$`)
+
+// hasTokens reports whether the parser understood anything on the line, a
+// line without tokens is blank or not understood
+func (hf HurlFile) hasTokens(line int) bool {
+	_, found := slices.BinarySearchFunc(hf.Tokens, line, func(t Token, line int) int {
+		return t.Range.StartLine - line
+	})
+
+	return found
+}
+
+// OptionAt returns the option on the line when it is in an [Options] section.
+// onValue tells whether the cursor is after the colon, name is "" when the
+// line has no colon yet.
+func (hf HurlFile) OptionAt(line, col int) (name string, onValue, ok bool) {
+	sec, ok := hf.SectionAt(line)
+	if !ok || sec.Name.Value != Options || line == sec.Range.StartLine {
+		return "", false, false
+	}
+
+	for _, pair := range sec.Pairs {
+		if pair.Range.StartLine == line {
+			return pair.Key.Value, col >= pair.Key.Range.EndCol, true
+		}
+	}
+
+	return "", false, true
+}
+
 func (hf HurlFile) CanUseFilter(line, col int) bool {
 	for _, entry := range hf.Entries {
 		if entry.Response == nil {
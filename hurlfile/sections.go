@@ -0,0 +1,220 @@
+package hurlfile
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ethancarlsson/hurl-lsp/builtin"
+)
+
+const Assert = "Asserts"
+
+const (
+	QueryStringParams = "QueryStringParams"
+	FormParams        = "FormParams"
+	MultipartFormData = "MultipartFormData"
+	Cookies           = "Cookies"
+	BasicAuth         = "BasicAuth"
+	Options           = "Options"
+)
+
+var requestSections = map[string]bool{
+	QueryStringParams: true,
+	"Query":           true,
+	FormParams:        true,
+	"Form":            true,
+	MultipartFormData: true,
+	"Multipart":       true,
+	Cookies:           true,
+	BasicAuth:         true,
+	Options:           true,
+}
+
+var responseSections = map[string]bool{
+	Capture: true,
+	Assert:  true,
+}
+
+// sectionAliases are the short names of request sections
+var sectionAliases = map[string]string{
+	"Query":     QueryStringParams,
+	"Form":      FormParams,
+	"Multipart": MultipartFormData,
+}
+
+// CanonicalSection returns the full name of a section, e.g. QueryStringParams for Query
+func CanonicalSection(name string) string {
+	if full, ok := sectionAliases[name]; ok {
+		return full
+	}
+
+	return name
+}
+
+// RequestSections are the typed contents of the sections of a request. The
+// sections are also kept in Request.Sections as they were written. The
+// ranges are zero based and the end is exclusive.
+type RequestSections struct {
+	QueryParams []KeyValue
+	FormParams  []KeyValue
+	Multipart   []MultipartParam
+	Cookies     []KeyValue
+	// BasicAuth is nil when the request has no [BasicAuth] section
+	BasicAuth *Credentials
+	Options   []Option
+}
+
+// MultipartParam is a field of a [MultipartFormData] section, File is nil
+// unless the value is a file like "file,data.txt; text/plain".
+type MultipartParam struct {
+	KeyValue
+	File *MultipartFile
+}
+
+type MultipartFile struct {
+	Path Ranged[string]
+	// ContentType is nil when it isn't given and hurl guesses it from the path
+	ContentType *Ranged[string]
+}
+
+// Credentials is the "user: password" line of a [BasicAuth] section
+type Credentials struct {
+	User     Ranged[string]
+	Password Ranged[string]
+	Range    SourceRange
+}
+
+type OptionKind int
+
+const (
+	OptionString OptionKind = iota
+	OptionBool
+	OptionInteger
+	OptionDuration
+	OptionTemplate
+)
+
+// Option is a line of an [Options] section, the kind of the value is
+// inferred from how it's written.
+type Option struct {
+	Name  Ranged[string]
+	Value OptionValue
+	Range SourceRange
+}
+
+type OptionValue struct {
+	Kind OptionKind
+	Ranged[string]
+}
+
+var reInteger = regexp.MustCompile(`^-?\d+$`)
+var reDuration = regexp.MustCompile(`^\d+(?:ms|s|m|h)$`)
+
+// typeRequestSection adds the pairs of a request section to their typed node
+func (p *Parser) typeRequestSection(typed *RequestSections, sec *Section) {
+	switch CanonicalSection(sec.Name.Value) {
+	case QueryStringParams:
+		typed.QueryParams = append(typed.QueryParams, sec.Pairs...)
+	case FormParams:
+		typed.FormParams = append(typed.FormParams, sec.Pairs...)
+	case Cookies:
+		typed.Cookies = append(typed.Cookies, sec.Pairs...)
+	case MultipartFormData:
+		for _, pair := range sec.Pairs {
+			typed.Multipart = append(typed.Multipart, p.multipartParam(pair))
+		}
+	case BasicAuth:
+		for i, pair := range sec.Pairs {
+			if i > 0 || typed.BasicAuth != nil {
+				p.errorf(pair.Range, "[BasicAuth] must have a single \"user: password\" line")
+				continue
+			}
+
+			typed.BasicAuth = &Credentials{User: pair.Key, Password: pair.Value, Range: pair.Range}
+		}
+	case Options:
+		for _, pair := range sec.Pairs {
+			option := Option{
+				Name:  pair.Key,
+				Value: OptionValue{Kind: optionKind(pair.Value.Value), Ranged: pair.Value},
+				Range: pair.Range,
+			}
+			p.checkOption(option)
+			typed.Options = append(typed.Options, option)
+		}
+	}
+}
+
+func (p *Parser) multipartParam(pair KeyValue) MultipartParam {
+	param := MultipartParam{KeyValue: pair}
+	value := pair.Value.Value
+	if !strings.HasPrefix(value, "file,") {
+		return param
+	}
+
+	semicolon := strings.Index(value, ";")
+	if semicolon < 0 {
+		p.errorf(pair.Value.Range, "unterminated file parameter, expected a closing ; e.g. file,data.txt;")
+		return param
+	}
+
+	rng := pair.Value.Range
+	at := func(start, end int) SourceRange {
+		return SourceRange{StartLine: rng.StartLine, StartCol: rng.StartCol + start, EndLine: rng.EndLine, EndCol: rng.StartCol + end}
+	}
+
+	start := len("file,")
+	param.File = &MultipartFile{Path: Ranged[string]{Value: value[start:semicolon], Range: at(start, semicolon)}}
+	if contentType := strings.TrimSpace(value[semicolon+1:]); contentType != "" {
+		start := strings.Index(value[semicolon+1:], contentType) + semicolon + 1
+		param.File.ContentType = &Ranged[string]{Value: contentType, Range: at(start, start+len(contentType))}
+	}
+
+	return param
+}
+
+// checkOption reports unknown options and values of the wrong type. Values
+// that are templates are only known when hurl runs, so they are always valid.
+func (p *Parser) checkOption(option Option) {
+	name, value := option.Name.Value, option.Value
+	desc, ok := builtin.Options[name]
+	if !ok {
+		p.errorf(option.Name.Range, "unknown option %q", name)
+		return
+	}
+
+	if value.Value == "" {
+		p.errorf(option.Range, "missing value for the %s option", name)
+		return
+	}
+
+	if value.Kind == OptionTemplate {
+		return
+	}
+
+	switch {
+	case desc.Type == builtin.OptionBool && value.Kind != OptionBool:
+		p.errorf(value.Range, "the %s option expects a boolean, true or false", name)
+	case desc.Type == builtin.OptionInteger && value.Kind != OptionInteger:
+		p.errorf(value.Range, "the %s option expects an integer", name)
+	case desc.Type == builtin.OptionDuration && value.Kind != OptionInteger && value.Kind != OptionDuration:
+		p.errorf(value.Range, "the %s option expects a duration like 500ms, 2s or 1m", name)
+	case desc.Type == builtin.OptionVariable && !strings.Contains(value.Value, "="):
+		p.errorf(value.Range, "the %s option expects name=value", name)
+	}
+}
+
+func optionKind(value string) OptionKind {
+	switch {
+	case value == "true" || value == "false":
+		return OptionBool
+	case reInteger.MatchString(value):
+		return OptionInteger
+	case reDuration.MatchString(value):
+		return OptionDuration
+	case reTemplate.FindString(value) == value && value != "":
+		return OptionTemplate
+	default:
+		return OptionString
+	}
+}
@@ -0,0 +1,91 @@
+package hurlfile
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var reTemplate = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_-]*)\s*\}\}`)
+
+// Functions are generated by hurl so they never need to be defined
+var templateFunctions = map[string]bool{
+	"newUuid": true,
+	"newDate": true,
+}
+
+// recordTemplates records the variable names used in {{templates}} on the line.
+// Like diagnostics, the columns are zero based and the end is exclusive.
+func (p *Parser) recordTemplates(line string, lineNum int) {
+	for _, m := range reTemplate.FindAllStringSubmatchIndex(line, -1) {
+		p.templates = append(p.templates, Ranged[string]{
+			Value: line[m[2]:m[3]],
+			Range: SourceRange{StartLine: lineNum, StartCol: m[2], EndLine: lineNum, EndCol: m[3]},
+		})
+	}
+}
+
+// UndefinedTemplates returns the templates using a variable that isn't captured
+// before them, defined by an earlier [Options] variable, or in known.
+func (hf *HurlFile) UndefinedTemplates(known []string) []Ranged[string] {
+	undefined := make([]Ranged[string], 0)
+	caps := hf.Captures()
+	opts := hf.optionVariables()
+	for _, tmpl := range hf.Templates {
+		name := tmpl.Value
+		if templateFunctions[name] || slices.Contains(known, name) ||
+			slices.Contains(caps.Before(tmpl.Range.StartLine).Variables(), name) ||
+			slices.Contains(opts.Before(tmpl.Range.StartLine).Variables(), name) {
+			continue
+		}
+
+		undefined = append(undefined, tmpl)
+	}
+
+	return undefined
+}
+
+// optionVariables returns the variables defined with "variable: name=value"
+// in [Options] sections. Options are evaluated before the request is built, so
+// they can be used anywhere in their entry.
+func (hf *HurlFile) optionVariables() Captures {
+	vars := make(Captures, 0)
+	for _, entry := range hf.Entries {
+		for _, section := range entry.Request.Sections {
+			if section.Name.Value != Options {
+				continue
+			}
+
+			for _, pair := range section.Pairs {
+				name, _, found := strings.Cut(pair.Value.Value, "=")
+				if pair.Key.Value != "variable" || !found {
+					continue
+				}
+
+				vars = append(vars, CaptureVars{
+					UseAfter:  entry.Range.StartLine - 1,
+					Variables: []string{strings.TrimSpace(name)},
+				})
+			}
+		}
+	}
+
+	return vars
+}
+
+// VariableNames returns the names of the variables defined in a variables
+// file, where every line is "name=value".
+func VariableNames(lines []string) []string {
+	names := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, _, _ := strings.Cut(line, "=")
+		names = append(names, strings.TrimSpace(name))
+	}
+
+	return names
+}
@@ -0,0 +1,266 @@
+package hurlfile
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ethancarlsson/hurl-lsp/builtin"
+)
+
+type TokenKind int
+
+const (
+	TokenMethod TokenKind = iota
+	TokenURL
+	TokenVersion
+	TokenStatus
+	TokenKey
+	TokenValue
+	TokenSectionName
+	TokenVariable
+	TokenQuery
+	TokenFilter
+	TokenPredicate
+	TokenString
+	TokenRegex
+	TokenNumber
+	TokenKeyword
+	TokenTemplate
+	TokenComment
+	TokenBody
+	// TokenWhitespace, TokenNewline and TokenText are only found in the
+	// concrete syntax tree, they cover the text between the other tokens
+	TokenWhitespace
+	TokenNewline
+	TokenText
+)
+
+// Token is a classified part of a line. Tokens never span lines, the columns
+// are zero based and the end is exclusive.
+type Token struct {
+	Kind  TokenKind
+	Range SourceRange
+}
+
+var reNumber = regexp.MustCompile(`^-?\d+(?:\.\d+)?$`)
+
+var keywords = map[string]bool{"true": true, "false": true, "null": true}
+
+func (p *Parser) addToken(kind TokenKind, lineNum, start, end int) {
+	if end <= start {
+		return
+	}
+
+	p.tokens = append(p.tokens, Token{
+		Kind:  kind,
+		Range: SourceRange{StartLine: lineNum, StartCol: start, EndLine: lineNum, EndCol: end},
+	})
+}
+
+// addText adds a token of kind for line[start:end], splitting out any templates
+func (p *Parser) addText(kind TokenKind, line string, lineNum, start, end int) {
+	cursor := start
+	for _, m := range reTemplateBraces.FindAllStringIndex(line[start:end], -1) {
+		p.addToken(kind, lineNum, cursor, start+m[0])
+		p.addToken(TokenTemplate, lineNum, start+m[0], start+m[1])
+		cursor = start + m[1]
+	}
+
+	p.addToken(kind, lineNum, cursor, end)
+}
+
+var reTemplateBraces = regexp.MustCompile(`\{\{.*?\}\}`)
+
+func (p *Parser) tokenizeRequestLine(line string, lineNum int, method string) {
+	start := countLeadingWhitespace(line)
+	p.addToken(TokenMethod, lineNum, start, start+len(method))
+
+	urlStart := start + len(method)
+	urlStart += countLeadingWhitespace(line[urlStart:])
+	p.addText(TokenURL, line, lineNum, urlStart, valueEnd(line, urlStart))
+}
+
+func (p *Parser) tokenizeResponseLine(line string, lineNum int) {
+	for i, w := range lexWords(line, 0) {
+		kind := TokenStatus
+		if i == 0 {
+			kind = TokenVersion
+		}
+		p.addToken(kind, lineNum, w.start, w.end)
+	}
+}
+
+func (p *Parser) tokenizeSectionName(line string, lineNum int) {
+	start := strings.Index(line, "[")
+	end := strings.LastIndex(line, "]") + 1
+	p.addToken(TokenSectionName, lineNum, start, end)
+}
+
+// tokenizeKeyValue tokenizes "key: value" lines of headers and sections
+func (p *Parser) tokenizeKeyValue(line string, lineNum int, keyKind TokenKind) {
+	keyStart := countLeadingWhitespace(line)
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return
+	}
+	keyEnd := keyStart + len(strings.TrimRightFunc(line[keyStart:colon], isSpace))
+	p.addText(keyKind, line, lineNum, keyStart, keyEnd)
+
+	valueStart := colon + 1
+	valueStart += countLeadingWhitespace(line[valueStart:])
+	end := valueEnd(line, valueStart)
+	if end <= valueStart {
+		return
+	}
+
+	value := line[valueStart:end]
+	switch {
+	case reNumber.MatchString(value):
+		p.addToken(TokenNumber, lineNum, valueStart, end)
+	case keywords[value]:
+		p.addToken(TokenKeyword, lineNum, valueStart, end)
+	default:
+		p.addText(TokenValue, line, lineNum, valueStart, end)
+	}
+}
+
+// tokenizeQuery tokenizes a query, its filters and the predicate of an assert
+// starting from column start.
+func (p *Parser) tokenizeQuery(line string, lineNum, start int) {
+	seenPredicate := false
+	for i, w := range lexWords(line, start) {
+		text := line[w.start:w.end]
+		switch {
+		case w.kind == TokenString || w.kind == TokenRegex:
+			p.addText(w.kind, line, lineNum, w.start, w.end)
+		case w.kind == TokenTemplate:
+			p.addToken(TokenTemplate, lineNum, w.start, w.end)
+		case reNumber.MatchString(text):
+			p.addToken(TokenNumber, lineNum, w.start, w.end)
+		case keywords[text]:
+			p.addToken(TokenKeyword, lineNum, w.start, w.end)
+		case i == 0:
+			if _, ok := builtin.Queries[text]; ok {
+				p.addToken(TokenQuery, lineNum, w.start, w.end)
+			}
+		case seenPredicate:
+			// values after the predicate aren't filters
+		default:
+			if _, ok := builtin.Predicates[text]; ok {
+				seenPredicate = text != "not"
+				p.addToken(TokenPredicate, lineNum, w.start, w.end)
+			} else if _, ok := builtin.Filters[text]; ok {
+				p.addToken(TokenFilter, lineNum, w.start, w.end)
+			}
+		}
+	}
+}
+
+func (p *Parser) tokenizeCapture(line string, lineNum int) {
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return
+	}
+
+	keyStart := countLeadingWhitespace(line)
+	p.addToken(TokenVariable, lineNum, keyStart, keyStart+len(strings.TrimRightFunc(line[keyStart:colon], isSpace)))
+	p.tokenizeQuery(line, lineNum, colon+1)
+}
+
+func (p *Parser) tokenizeBody(line string, lineNum int) {
+	if strings.TrimSpace(line) == "" {
+		// blank lines in a body are whitespace, see buildSyntax
+		return
+	}
+
+	start := countLeadingWhitespace(line)
+	p.addText(TokenBody, line, lineNum, start, len(strings.TrimRightFunc(line, isSpace)))
+}
+
+// tokenizeComments adds a token for every comment line that isn't part of
+// something else, like a multiline string body.
+func (p *Parser) tokenizeComments() {
+	tokenized := make(map[int]bool, len(p.tokens))
+	for _, t := range p.tokens {
+		tokenized[t.Range.StartLine] = true
+	}
+
+	for i, line := range p.lines {
+		trim := strings.TrimSpace(line)
+		if !strings.HasPrefix(trim, "#") || tokenized[i] {
+			continue
+		}
+
+		start := countLeadingWhitespace(line)
+		p.addToken(TokenComment, i, start, start+len(trim))
+	}
+}
+
+func sortTokens(tokens []Token) {
+	slices.SortStableFunc(tokens, func(a, b Token) int {
+		if a.Range.StartLine != b.Range.StartLine {
+			return a.Range.StartLine - b.Range.StartLine
+		}
+
+		return a.Range.StartCol - b.Range.StartCol
+	})
+}
+
+type lexWord struct {
+	kind       TokenKind
+	start, end int
+}
+
+// lexWords splits the line from start on whitespace, keeping quoted strings,
+// regexes and templates as one word. Words that aren't strings, regexes or
+// templates have the TokenValue kind.
+func lexWords(line string, start int) []lexWord {
+	words := make([]lexWord, 0)
+	for i := start; i < len(line); {
+		c := line[i]
+		if c == ' ' || c == '\t' {
+			i++
+			continue
+		}
+
+		if c == '#' {
+			// trailing comment
+			break
+		}
+
+		wordStart := i
+		kind := TokenValue
+		switch {
+		case c == '"' || c == '`' || c == '/':
+			kind = TokenString
+			if c == '/' {
+				kind = TokenRegex
+			}
+			i++
+			for i < len(line) && line[i] != c {
+				if line[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			i = min(i+1, len(line))
+		case strings.HasPrefix(line[i:], "{{"):
+			kind = TokenTemplate
+			end := strings.Index(line[i:], "}}")
+			if end < 0 {
+				i = len(line)
+			} else {
+				i += end + 2
+			}
+		default:
+			for i < len(line) && line[i] != ' ' && line[i] != '\t' {
+				i++
+			}
+		}
+
+		words = append(words, lexWord{kind: kind, start: wordStart, end: i})
+	}
+
+	return words
+}
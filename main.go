@@ -3,13 +3,23 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
+	"github.com/ethancarlsson/hurl-lsp/codeactions"
 	"github.com/ethancarlsson/hurl-lsp/completions"
+	"github.com/ethancarlsson/hurl-lsp/diagnostics"
+	"github.com/ethancarlsson/hurl-lsp/document"
+	"github.com/ethancarlsson/hurl-lsp/format"
+	"github.com/ethancarlsson/hurl-lsp/hover"
 	"github.com/ethancarlsson/hurl-lsp/hurlfile"
 	"github.com/ethancarlsson/hurl-lsp/openapi"
+	"github.com/ethancarlsson/hurl-lsp/semantictokens"
 	"github.com/ethancarlsson/hurl-lsp/signaturehelp"
+	"github.com/ethancarlsson/hurl-lsp/symbols"
 	"github.com/tliron/commonlog"
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
@@ -35,31 +45,63 @@ func (p oaiPath) Ft() string {
 
 type config struct {
 	OpenapiDefPath oaiPath `json:"openapi_def"`
+	// Openapi are specs for some of the requests, e.g. of one service in a
+	// workspace that tests several
+	Openapi []specConfig `json:"openapi"`
+	// VariablesFile is a hurl --variables-file with name=value on every line
+	VariablesFile string `json:"variables_file"`
+	// Variables are the names of variables passed to hurl in other ways
+	// e.g. with --variable or HURL_name environment variables
+	Variables []string `json:"variables"`
+}
+
+// specConfig ties a spec to the requests starting with BaseURL or to the
+// hurl files matching the Files glob, relative to the workspace
+type specConfig struct {
+	Path    oaiPath `json:"path"`
+	BaseURL string  `json:"base_url"`
+	Files   string  `json:"files"`
 }
 
 var (
 	version string = "0.0.1"
 	handler protocol.Handler
-	lines   []string           = []string{}
-	hf      *hurlfile.HurlFile = &hurlfile.HurlFile{}
+	docs    *document.Store       = document.NewStore()
+	tokens  *semantictokens.Cache = semantictokens.NewCache()
 
-	conf config      = config{}
-	oai  openapi.OAI = openapi.OAI{}
-	errs []error     = []error{}
+	conf         config        = config{}
+	specs        openapi.Specs = openapi.Specs{}
+	fileVarNames []string      = []string{}
+	errs         []error       = []error{}
 )
 
 func main() {
 	commonlog.Configure(1, nil)
 
 	handler = protocol.Handler{
-		Initialize:                initialize,
-		Initialized:               initialized,
-		Shutdown:                  shutdown,
-		SetTrace:                  setTrace,
-		TextDocumentCompletion:    completion,
-		TextDocumentSignatureHelp: signatureHelp,
-		TextDocumentDidOpen:       documentDidOpen,
-		TextDocumentDidChange:     documentDidChange,
+		Initialize:                 initialize,
+		Initialized:                initialized,
+		Shutdown:                   shutdown,
+		SetTrace:                   setTrace,
+		TextDocumentCompletion:     completion,
+		TextDocumentSignatureHelp:  signatureHelp,
+		TextDocumentHover:          documentHover,
+		TextDocumentDefinition:     definition,
+		TextDocumentReferences:     references,
+		TextDocumentPrepareRename:  prepareRename,
+		TextDocumentRename:         rename,
+		TextDocumentDocumentSymbol: documentSymbol,
+		TextDocumentFoldingRange:   foldingRange,
+		TextDocumentCodeAction:     codeAction,
+
+		TextDocumentFormatting:              formatting,
+		TextDocumentRangeFormatting:         rangeFormatting,
+		TextDocumentSemanticTokensFull:      semanticTokensFull,
+		TextDocumentSemanticTokensFullDelta: semanticTokensFullDelta,
+		TextDocumentSemanticTokensRange:     semanticTokensRange,
+		TextDocumentDidOpen:                 documentDidOpen,
+		TextDocumentDidChange:               documentDidChange,
+		TextDocumentDidClose:                documentDidClose,
 	}
 
 	server := server.NewServer(&handler, lsName, false)
@@ -68,46 +110,70 @@ func main() {
 }
 
 func documentDidOpen(context *glsp.Context, params *protocol.DidOpenTextDocumentParams) error {
-	if err := parseDocument(params.TextDocument.URI); err != nil {
-		return err
+	doc, err := docs.Open(params.TextDocument.URI, params.TextDocument.Version, params.TextDocument.Text)
+	if err != nil {
+		return fmt.Errorf("Failed to parse the hurl file %w", err)
 	}
 
+	publishDiagnostics(context, doc)
+
 	return nil
 }
 
 func documentDidChange(context *glsp.Context, params *protocol.DidChangeTextDocumentParams) error {
-	if err := parseDocument(params.TextDocument.URI); err != nil {
-		return err
+	doc, err := docs.Change(params.TextDocument.URI, params.TextDocument.Version, params.ContentChanges)
+	if err != nil {
+		return fmt.Errorf("Failed to parse the hurl file %w", err)
 	}
 
+	publishDiagnostics(context, doc)
+
 	return nil
 }
 
-func parseDocument(uri string) error {
-	parsedLines, err := hurlfile.ParseLines(uri)
-	if err != nil {
-		return fmt.Errorf("Failed to parse the hurl file %w", err)
-	}
+func documentDidClose(context *glsp.Context, params *protocol.DidCloseTextDocumentParams) error {
+	docs.Close(params.TextDocument.URI)
+	tokens.Evict(params.TextDocument.URI)
+	// Clear the diagnostics of the closed document
+	context.Notify(protocol.ServerTextDocumentPublishDiagnostics, protocol.PublishDiagnosticsParams{
+		URI:         params.TextDocument.URI,
+		Diagnostics: []protocol.Diagnostic{},
+	})
 
-	lines = parsedLines
+	return nil
+}
 
-	hf, err = hurlfile.Parse(lines)
-	if err != nil {
-		return fmt.Errorf("Failed to parse the hurl file %w", err)
-	}
+func publishDiagnostics(context *glsp.Context, doc *document.Document) {
+	version := protocol.UInteger(doc.Version)
+	diags := diagnostics.Parse(doc.HurlFile, doc.Lines)
+	diags = append(diags, diagnostics.UndefinedVariables(doc.HurlFile, doc.Lines, externalVariables())...)
+	diags = append(diags, diagnostics.JSONBodies(doc.HurlFile, doc.Lines)...)
+	diags = append(diags, diagnostics.RequestBodies(doc.HurlFile, doc.Lines, specsOf(doc.URI))...)
+	diags = append(diags, diagnostics.Operations(doc.HurlFile, doc.Lines, specsOf(doc.URI))...)
+
+	context.Notify(protocol.ServerTextDocumentPublishDiagnostics, protocol.PublishDiagnosticsParams{
+		URI:         doc.URI,
+		Version:     &version,
+		Diagnostics: diags,
+	})
+}
 
-	return nil
+// externalVariables are the variables that are defined outside of hurl files
+func externalVariables() []string {
+	return append(slices.Clone(conf.Variables), fileVarNames...)
 }
 
 func signatureHelp(context *glsp.Context, params *protocol.SignatureHelpParams) (*protocol.SignatureHelp, error) {
-	// Reparse on signatureHelp because 1) signatureHelp will not be called as often,
-	// and; 2) because we can end up 1 character behind when relying on onChanged
-	parseDocument(params.TextDocument.URI)
+	doc, ok := docs.Get(params.TextDocument.URI)
+	if !ok {
+		return nil, nil
+	}
+	hf := doc.HurlFile
 
 	line := int(params.Position.Line)
-	col := int(params.Position.Character) - 1 // zero base
+	col := document.Column(doc.Lines, params.Position) - 1 // zero base
 
-	sym := signaturehelp.Lines(lines).SymbolAt(line, col)
+	sym := signaturehelp.Lines(doc.Lines).SymbolAt(line, col)
 	if desc := sym.Description(); desc.Desctiption != "" {
 		help := protocol.SignatureHelp{Signatures: []protocol.SignatureInformation{
 			{
@@ -122,13 +188,9 @@ func signatureHelp(context *glsp.Context, params *protocol.SignatureHelpParams)
 		return &help, nil
 	}
 
-	if hf == nil {
-		return nil, nil
-	}
-
 	if hf.OnMethod(line, col) || hf.OnUri(line, col) {
 		req := hf.GetReq(line, col)
-		op := oai.GetOp(req.Method.Name, req.Target.Target)
+		op := specsOf(doc.URI).Find(req.Target.Target).GetOp(req.Method.Name, req.Target.Target)
 		help := protocol.SignatureHelp{Signatures: []protocol.SignatureInformation{
 			{
 				Label: op.Method + " " + op.Path,
@@ -149,17 +211,29 @@ func signatureHelp(context *glsp.Context, params *protocol.SignatureHelpParams)
 
 func completion(context *glsp.Context, params *protocol.CompletionParams) (any, error) {
 	items := make([]protocol.CompletionItem, 0)
-	if hf == nil {
+	doc, ok := docs.Get(params.TextDocument.URI)
+	if !ok {
 		return items, nil
 	}
+	hf := doc.HurlFile
 
 	line := int(params.Position.Line)
-	col := int(params.Position.Character) - 1 // zero base
+	col := document.Column(doc.Lines, params.Position) - 1 // zero base
+
+	if hf.OnReqSectionName(line, col) {
+		items = completions.AddReqSection(items)
+	}
 
 	if hf.OnRespSectionName(line, col) {
 		items = completions.AddRespSection(items)
 	}
 
+	if option, onValue, ok := hf.OptionAt(line, col); ok && onValue {
+		items = completions.AddOptionValues(items, option)
+	} else if ok {
+		items = completions.AddOptions(items)
+	}
+
 	if caps := hf.Captures().Before(line); len(caps) > 0 {
 		items = completions.AddVars(items, caps.Variables())
 	}
@@ -169,14 +243,255 @@ func completion(context *glsp.Context, params *protocol.CompletionParams) (any,
 	}
 
 	if hf.OnUri(line, col) {
-		items = completions.AddPaths(items, oai.PathList())
+		target := hf.GetReq(line, col).Target.Target
+		items = completions.AddPaths(items, specsOf(doc.URI).Find(target).PathList())
 	}
 
 	return items, nil
 }
 
+func documentHover(context *glsp.Context, params *protocol.HoverParams) (*protocol.Hover, error) {
+	doc, ok := docs.Get(params.TextDocument.URI)
+	if !ok {
+		return nil, nil
+	}
+	hf := doc.HurlFile
+
+	line := int(params.Position.Line)
+	col := document.Column(doc.Lines, params.Position)
+
+	md := hover.Hurl(hf, doc.Lines, line, col)
+	if req := hf.GetReq(line, col); md == "" && req.Method.Name != "" && req.Range.StartLine == line {
+		// requests without a spec aren't documented anywhere
+		if oai := specsOf(doc.URI).Find(req.Target.Target); len(oai.Paths) > 0 {
+			md = hover.Operation(oai.GetOp(req.Method.Name, req.Target.Target))
+		}
+	}
+
+	if md == "" {
+		return nil, nil
+	}
+
+	return &protocol.Hover{
+		Contents: protocol.MarkupContent{Kind: protocol.MarkupKindMarkdown, Value: md},
+	}, nil
+}
+
+func definition(context *glsp.Context, params *protocol.DefinitionParams) (any, error) {
+	doc, ok := docs.Get(params.TextDocument.URI)
+	if !ok {
+		return nil, nil
+	}
+
+	tmpl, ok := doc.HurlFile.TemplateAt(int(params.Position.Line), document.Column(doc.Lines, params.Position))
+	if !ok {
+		return nil, nil
+	}
+
+	def, ok := doc.HurlFile.DefinitionOf(tmpl)
+	if !ok {
+		return nil, nil
+	}
+
+	return protocol.Location{
+		URI:   doc.URI,
+		Range: document.Range(doc.Lines, def.Range),
+	}, nil
+}
+
+func references(context *glsp.Context, params *protocol.ReferenceParams) ([]protocol.Location, error) {
+	doc, ok := docs.Get(params.TextDocument.URI)
+	if !ok {
+		return nil, nil
+	}
+
+	v, ok := doc.HurlFile.VariableAt(int(params.Position.Line), document.Column(doc.Lines, params.Position))
+	if !ok {
+		return nil, nil
+	}
+
+	refs := doc.HurlFile.References(v.Value, params.Context.IncludeDeclaration)
+	locs := make([]protocol.Location, 0, len(refs))
+	for _, ref := range refs {
+		locs = append(locs, protocol.Location{URI: doc.URI, Range: document.Range(doc.Lines, ref.Range)})
+	}
+
+	return locs, nil
+}
+
+func prepareRename(context *glsp.Context, params *protocol.PrepareRenameParams) (any, error) {
+	doc, ok := docs.Get(params.TextDocument.URI)
+	if !ok {
+		return nil, nil
+	}
+
+	v, err := renamableVariableAt(doc, params.Position)
+	if err != nil || v == nil {
+		return nil, err
+	}
+
+	return protocol.RangeWithPlaceholder{Range: document.Range(doc.Lines, v.Range), Placeholder: v.Value}, nil
+}
+
+func rename(context *glsp.Context, params *protocol.RenameParams) (*protocol.WorkspaceEdit, error) {
+	doc, ok := docs.Get(params.TextDocument.URI)
+	if !ok {
+		return nil, nil
+	}
+
+	v, err := renamableVariableAt(doc, params.Position)
+	if err != nil || v == nil {
+		return nil, err
+	}
+
+	if !hurlfile.ValidVariableName(params.NewName) {
+		return nil, fmt.Errorf("%q is not a valid variable name", params.NewName)
+	}
+
+	if params.NewName != v.Value && (doc.HurlFile.IsCaptured(params.NewName) || slices.Contains(externalVariables(), params.NewName)) {
+		return nil, fmt.Errorf("a variable named %q already exists", params.NewName)
+	}
+
+	refs := doc.HurlFile.References(v.Value, true)
+	edits := make([]protocol.TextEdit, 0, len(refs))
+	for _, ref := range refs {
+		edits = append(edits, protocol.TextEdit{Range: document.Range(doc.Lines, ref.Range), NewText: params.NewName})
+	}
+
+	return &protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentUri][]protocol.TextEdit{doc.URI: edits},
+	}, nil
+}
+
+// renamableVariableAt returns the variable at the position if there is one.
+// Only variables captured in the file can be renamed, otherwise we would
+// rename uses of a variable that is defined somewhere we can't edit.
+func renamableVariableAt(doc *document.Document, pos protocol.Position) (*hurlfile.Ranged[string], error) {
+	hf := doc.HurlFile
+	v, ok := hf.VariableAt(int(pos.Line), document.Column(doc.Lines, pos))
+	if !ok {
+		return nil, nil
+	}
+
+	if !hf.IsCaptured(v.Value) {
+		return nil, fmt.Errorf("%q is not captured in this file so it can't be renamed", v.Value)
+	}
+
+	return &v, nil
+}
+
+func documentSymbol(context *glsp.Context, params *protocol.DocumentSymbolParams) (any, error) {
+	doc, ok := docs.Get(params.TextDocument.URI)
+	if !ok {
+		return nil, nil
+	}
+
+	return symbols.Document(doc.HurlFile, doc.Lines), nil
+}
+
+func foldingRange(context *glsp.Context, params *protocol.FoldingRangeParams) ([]protocol.FoldingRange, error) {
+	doc, ok := docs.Get(params.TextDocument.URI)
+	if !ok {
+		return nil, nil
+	}
+
+	return symbols.Folding(doc.HurlFile, doc.Lines), nil
+}
+
+func formatting(context *glsp.Context, params *protocol.DocumentFormattingParams) ([]protocol.TextEdit, error) {
+	doc, ok := docs.Get(params.TextDocument.URI)
+	if !ok {
+		return nil, nil
+	}
+
+	last := len(doc.Lines) - 1
+	return []protocol.TextEdit{{
+		Range:   protocol.Range{End: document.Position(doc.Lines, last, len(doc.Lines[last]))},
+		NewText: format.Document(doc.HurlFile, doc.Lines, formatOptions(params.Options)),
+	}}, nil
+}
+
+func rangeFormatting(context *glsp.Context, params *protocol.DocumentRangeFormattingParams) ([]protocol.TextEdit, error) {
+	doc, ok := docs.Get(params.TextDocument.URI)
+	if !ok {
+		return nil, nil
+	}
+
+	text, from, to, ok := format.Range(
+		doc.HurlFile,
+		doc.Lines,
+		int(params.Range.Start.Line),
+		int(params.Range.End.Line),
+		formatOptions(params.Options),
+	)
+	if !ok {
+		return nil, nil
+	}
+
+	// Replace up to the start of the next line, the formatted text ends with a new line
+	end := protocol.Position{Line: protocol.UInteger(to + 1)}
+	if to+1 >= len(doc.Lines) {
+		end = document.Position(doc.Lines, to, len(doc.Lines[to]))
+		text = strings.TrimSuffix(text, "\n")
+	}
+
+	return []protocol.TextEdit{{
+		Range:   protocol.Range{Start: protocol.Position{Line: protocol.UInteger(from)}, End: end},
+		NewText: text,
+	}}, nil
+}
+
+// formatOptions indents JSON bodies the same way as the editor
+func formatOptions(opts protocol.FormattingOptions) format.Options {
+	if spaces, ok := opts[protocol.FormattingOptionInsertSpaces].(bool); ok && !spaces {
+		return format.Options{Indent: "\t"}
+	}
+
+	// Numbers are float64 when they come from JSON
+	if size, ok := opts[protocol.FormattingOptionTabSize].(float64); ok && size > 0 {
+		return format.Options{Indent: strings.Repeat(" ", int(size))}
+	}
+
+	return format.Options{}
+}
+
+func semanticTokensFull(context *glsp.Context, params *protocol.SemanticTokensParams) (*protocol.SemanticTokens, error) {
+	doc, ok := docs.Get(params.TextDocument.URI)
+	if !ok {
+		return nil, nil
+	}
+
+	return tokens.Full(doc.URI, semantictokens.Encode(doc.HurlFile.Tokens, doc.Lines, nil)), nil
+}
+
+func semanticTokensFullDelta(context *glsp.Context, params *protocol.SemanticTokensDeltaParams) (any, error) {
+	doc, ok := docs.Get(params.TextDocument.URI)
+	if !ok {
+		return nil, nil
+	}
+
+	data := semantictokens.Encode(doc.HurlFile.Tokens, doc.Lines, nil)
+
+	return tokens.Delta(doc.URI, params.PreviousResultID, data), nil
+}
+
+func semanticTokensRange(context *glsp.Context, params *protocol.SemanticTokensRangeParams) (any, error) {
+	doc, ok := docs.Get(params.TextDocument.URI)
+	if !ok {
+		return nil, nil
+	}
+
+	return &protocol.SemanticTokens{
+		Data: semantictokens.Encode(doc.HurlFile.Tokens, doc.Lines, &params.Range),
+	}, nil
+}
+
 func initialize(context *glsp.Context, params *protocol.InitializeParams) (any, error) {
 	capabilities := handler.CreateServerCapabilities()
+	syncKind := document.SyncKind
+	capabilities.TextDocumentSync.(*protocol.TextDocumentSyncOptions).Change = &syncKind
+	capabilities.RenameProvider = protocol.RenameOptions{PrepareProvider: &protocol.True}
+	capabilities.SemanticTokensProvider.(*protocol.SemanticTokensOptions).Legend = semantictokens.Legend
 
 	return protocol.InitializeResult{
 		Capabilities: capabilities,
@@ -198,7 +513,11 @@ func initialized(context *glsp.Context, params *protocol.InitializedParams) erro
 		return err
 	}
 
-	if conf.OpenapiDefPath == "" {
+	if conf.VariablesFile != "" {
+		parseVariablesFile()
+	}
+
+	if conf.OpenapiDefPath == "" && len(conf.Openapi) == 0 {
 		return nil
 	}
 
@@ -207,28 +526,94 @@ func initialized(context *glsp.Context, params *protocol.InitializedParams) erro
 	return nil
 }
 
+func parseVariablesFile() {
+	fileContent, err := os.ReadFile(conf.VariablesFile)
+	if err != nil {
+		if m := commonlog.NewErrorMessage(0); m != nil {
+			m.Set("_message", "Could not read variables file").
+				Set("err", err).Send()
+		}
+		errs = append(errs, err)
+		return
+	}
+
+	fileVarNames = hurlfile.VariableNames(document.SplitLines(string(fileContent)))
+}
+
+// parseOpenapi parses the specs of the config, a spec that can't be parsed is
+// left out
 func parseOpenapi() {
-	fileContent, err := os.ReadFile(string(conf.OpenapiDefPath))
+	parsed := make(openapi.Specs, 0, len(conf.Openapi)+1)
+	if conf.OpenapiDefPath != "" {
+		if oai, ok := parseSpec(conf.OpenapiDefPath); ok {
+			parsed = append(parsed, openapi.NewSpec(oai, "", ""))
+		}
+	}
+
+	for _, sc := range conf.Openapi {
+		oai, ok := parseSpec(sc.Path)
+		if !ok {
+			continue
+		}
+
+		files := sc.Files
+		if files != "" && !filepath.IsAbs(files) {
+			if abs, err := filepath.Abs(files); err == nil {
+				files = abs
+			}
+		}
+		parsed = append(parsed, openapi.NewSpec(oai, sc.BaseURL, files))
+	}
+
+	specs = parsed
+}
+
+func parseSpec(path oaiPath) (openapi.OAI, bool) {
+	fileContent, err := os.ReadFile(string(path))
 	if err != nil {
 		if m := commonlog.NewErrorMessage(0); m != nil {
 			m.Set("_message", "Could not read openapi file").
+				Set("path", path).
 				Set("err", err).Send()
 		}
 		errs = append(errs, err)
-		return
+		return openapi.OAI{}, false
 	}
 
-	openAPI, err := openapi.Parse(conf.OpenapiDefPath.Ft(), fileContent)
+	oai, err := openapi.Parse(path.Ft(), fileContent)
 	if err != nil {
 		if m := commonlog.NewErrorMessage(0); m != nil {
 			m.Set("_message", "Could not parse openapi file").
+				Set("path", path).
 				Set("err", err).Send()
 		}
 		errs = append(errs, err)
-		return
+		return openapi.OAI{}, false
+	}
+
+	return oai, true
+}
+
+// specsOf returns the specs that can document the requests of a document
+func specsOf(uri protocol.DocumentUri) openapi.Finder {
+	path := strings.TrimPrefix(uri, "file://")
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		path = unescaped
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	return specs.ForFile(path)
+}
+
+func codeAction(context *glsp.Context, params *protocol.CodeActionParams) (any, error) {
+	doc, ok := docs.Get(params.TextDocument.URI)
+	if !ok {
+		return nil, nil
 	}
 
-	oai = openAPI
+	return codeactions.Operations(doc.HurlFile, doc.Lines, specsOf(doc.URI), doc.URI, params.Range), nil
 }
 
 func shutdown(context *glsp.Context) error {
@@ -1,23 +1,34 @@
 package main
 
 import (
+	"fmt"
+	"os"
+	"strings"
 	"testing"
 
+	"github.com/ethancarlsson/hurl-lsp/builtin"
 	"github.com/ethancarlsson/hurl-lsp/expect"
+	"github.com/ethancarlsson/hurl-lsp/openapi"
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
 func TestCompletion(t *testing.T) {
-	ctx := glsp.Context{}
+	ctx := testContext(nil)
 
 	t.Cleanup(func() {
 		conf.OpenapiDefPath = ""
+		specs = openapi.Specs{}
 	})
 
 	t.Run("no hurlfile", func(t *testing.T) {
-		hf = nil
-		is, err := completion(&ctx, nil)
+		is, err := completion(ctx, &protocol.CompletionParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{
+					URI: "./fixtures/not_opened.hurl",
+				},
+			},
+		})
 		expect.NoErr(t, err)
 
 		items := is.([]protocol.CompletionItem)
@@ -37,8 +48,8 @@ func TestCompletion(t *testing.T) {
 			},
 		}
 
-		parseDocument(params.TextDocument.URI)
-		is, err := completion(&ctx, params)
+		openFixture(t, params.TextDocument.URI)
+		is, err := completion(ctx, params)
 		expect.NoErr(t, err)
 
 		items := is.([]protocol.CompletionItem)
@@ -60,6 +71,85 @@ func TestCompletion(t *testing.T) {
 		}, items)
 	})
 
+	t.Run("req section", func(t *testing.T) {
+		uri := "file:///req_section.hurl"
+		err := documentDidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+			TextDocument: protocol.TextDocumentItem{URI: uri, Version: 1, Text: "GET /pets\n\nHTTP 200\n"},
+		})
+		expect.NoErr(t, err)
+
+		is, err := completion(ctx, &protocol.CompletionParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+				Position:     protocol.Position{Line: 1, Character: 0},
+			},
+		})
+		expect.NoErr(t, err)
+
+		items := is.([]protocol.CompletionItem)
+		expect.Equals(t, 9, len(items))
+		expect.Equals(t, "QueryStringParams", items[0].Label)
+		expect.Equals(t, "[QueryStringParams]\n${1:name}: ${2:value}", *items[0].InsertText)
+		expect.Equals(t, protocol.InsertTextFormatSnippet, *items[0].InsertTextFormat)
+		expect.Equals(t, "[BasicAuth]\n${1:user}: ${2:password}", *items[7].InsertText)
+	})
+
+	t.Run("req section after headers", func(t *testing.T) {
+		uri := "file:///req_section_headers.hurl"
+		err := documentDidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+			TextDocument: protocol.TextDocumentItem{URI: uri, Version: 1, Text: "GET /pets\nAccept: application/json\n[\nHTTP 200\n"},
+		})
+		expect.NoErr(t, err)
+
+		is, err := completion(ctx, &protocol.CompletionParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+				Position:     protocol.Position{Line: 2, Character: 1},
+			},
+		})
+		expect.NoErr(t, err)
+
+		items := is.([]protocol.CompletionItem)
+		expect.Equals(t, 9, len(items))
+		expect.Equals(t, "QueryStringParams", items[0].Label)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		uri := "file:///options.hurl"
+		err := documentDidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+			TextDocument: protocol.TextDocumentItem{URI: uri, Version: 1, Text: "GET /pets\n[Options]\nins\ninsecure: \ndelay: 1\n"},
+		})
+		expect.NoErr(t, err)
+		complete := func(line, char protocol.UInteger) []protocol.CompletionItem {
+			is, err := completion(ctx, &protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+					Position:     protocol.Position{Line: line, Character: char},
+				},
+			})
+			expect.NoErr(t, err)
+
+			return is.([]protocol.CompletionItem)
+		}
+
+		items := complete(2, 3)
+		expect.Equals(t, len(builtin.Options), len(items))
+		for _, item := range items {
+			_, ok := builtin.Options[item.Label]
+			expect.Equals(t, true, ok)
+		}
+
+		labels := func(items []protocol.CompletionItem) []string {
+			l := make([]string, 0, len(items))
+			for _, item := range items {
+				l = append(l, item.Label)
+			}
+			return l
+		}
+		expect.Equals(t, []string{"true", "false"}, labels(complete(3, 10)))
+		expect.Equals(t, []string{"500ms", "1s", "1m"}, labels(complete(4, 8)))
+	})
+
 	t.Run("Captured variables in completions", func(t *testing.T) {
 		params := &protocol.CompletionParams{
 			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
@@ -73,8 +163,8 @@ func TestCompletion(t *testing.T) {
 			},
 		}
 
-		parseDocument(params.TextDocument.URI)
-		is, err := completion(&ctx, params)
+		openFixture(t, params.TextDocument.URI)
+		is, err := completion(ctx, params)
 		expect.NoErr(t, err)
 
 		items := is.([]protocol.CompletionItem)
@@ -91,7 +181,7 @@ func TestCompletion(t *testing.T) {
 
 		// name shouldn't be available until it is captured
 		params.Position.Line = 6
-		is, err = completion(&ctx, params)
+		is, err = completion(ctx, params)
 		expect.NoErr(t, err)
 
 		items = is.([]protocol.CompletionItem)
@@ -114,16 +204,540 @@ func TestCompletion(t *testing.T) {
 		}
 
 		parseOpenapi()
-		parseDocument(params.TextDocument.URI)
+		openFixture(t, params.TextDocument.URI)
 
-		is, err := completion(&ctx, params)
+		is, err := completion(ctx, params)
 		expect.NoErr(t, err)
 
 		items := is.([]protocol.CompletionItem)
 		expect.Equals(t, 13, len(items))
 		for _, item := range items {
-			_, ok := oai.Paths[item.Label]
+			_, ok := specs[0].OAI.Paths[item.Label]
 			expect.Equals(t, true, ok)
 		}
 	})
 }
+
+func openFixture(t *testing.T, uri string) {
+	t.Helper()
+
+	contents, err := os.ReadFile(uri)
+	expect.NoErr(t, err)
+
+	err = documentDidOpen(testContext(nil), &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:        uri,
+			LanguageID: "hurl",
+			Version:    1,
+			Text:       string(contents),
+		},
+	})
+	expect.NoErr(t, err)
+}
+
+func TestDocumentDidChange(t *testing.T) {
+	ctx := testContext(nil)
+	uri := "./fixtures/test_captures.hurl"
+	openFixture(t, uri)
+
+	// Add an unsaved capture to the first entry
+	err := documentDidChange(ctx, &protocol.DidChangeTextDocumentParams{
+		TextDocument: protocol.VersionedTextDocumentIdentifier{
+			TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: uri},
+			Version:                2,
+		},
+		ContentChanges: []any{
+			protocol.TextDocumentContentChangeEvent{
+				Range: &protocol.Range{
+					Start: protocol.Position{Line: 3, Character: 22},
+					End:   protocol.Position{Line: 3, Character: 22},
+				},
+				Text: "\nstatus: jsonpath \"$[0].status\"",
+			},
+		},
+	})
+	expect.NoErr(t, err)
+
+	is, err := completion(ctx, &protocol.CompletionParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Position:     protocol.Position{Line: 7, Character: 2},
+		},
+	})
+	expect.NoErr(t, err)
+
+	items := is.([]protocol.CompletionItem)
+	expect.Equals(t, 2, len(items))
+}
+
+func TestMultipleDocuments(t *testing.T) {
+	ctx := testContext(nil)
+	captures := "./fixtures/test_captures.hurl"
+	openFixture(t, captures)
+	openFixture(t, "./fixtures/test.hurl")
+
+	params := &protocol.CompletionParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: captures},
+			Position:     protocol.Position{Line: 11, Character: 2},
+		},
+	}
+
+	// test.hurl was opened last but completion should use test_captures.hurl
+	is, err := completion(ctx, params)
+	expect.NoErr(t, err)
+
+	items := is.([]protocol.CompletionItem)
+	expect.Equals(t, 2, len(items))
+	expect.Equals(t, "id", items[0].Label)
+	expect.Equals(t, "name", items[1].Label)
+
+	err = documentDidClose(ctx, &protocol.DidCloseTextDocumentParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: captures},
+	})
+	expect.NoErr(t, err)
+
+	is, err = completion(ctx, params)
+	expect.NoErr(t, err)
+	expect.Equals(t, 0, len(is.([]protocol.CompletionItem)))
+}
+
+// testContext creates a context that records published diagnostics in diags
+// when it isn't nil.
+func testContext(diags *[]protocol.PublishDiagnosticsParams) *glsp.Context {
+	return &glsp.Context{
+		Notify: func(method string, params any) {
+			if diags != nil && method == protocol.ServerTextDocumentPublishDiagnostics {
+				*diags = append(*diags, params.(protocol.PublishDiagnosticsParams))
+			}
+		},
+	}
+}
+
+func TestPublishDiagnostics(t *testing.T) {
+	conf.Variables = []string{"jwt"}
+	t.Cleanup(func() {
+		conf.Variables = nil
+	})
+
+	uri := "./fixtures/test_partial_req.hurl"
+	contents, err := os.ReadFile(uri)
+	expect.NoErr(t, err)
+
+	published := []protocol.PublishDiagnosticsParams{}
+	ctx := testContext(&published)
+	err = documentDidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: uri, Version: 1, Text: string(contents)},
+	})
+	expect.NoErr(t, err)
+
+	expect.Equals(t, 1, len(published))
+	expect.Equals(t, uri, published[0].URI)
+	expect.Equals(t, 1, len(published[0].Diagnostics))
+	diag := published[0].Diagnostics[0]
+	expect.Equals(t, "unknown section [Cap]", diag.Message)
+	expect.Equals(t, protocol.DiagnosticSeverityError, *diag.Severity)
+	expect.Equals(t, protocol.Range{
+		Start: protocol.Position{Line: 8, Character: 0},
+		End:   protocol.Position{Line: 8, Character: 5},
+	}, diag.Range)
+
+	err = documentDidChange(ctx, &protocol.DidChangeTextDocumentParams{
+		TextDocument: protocol.VersionedTextDocumentIdentifier{
+			TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: uri},
+			Version:                2,
+		},
+		ContentChanges: []any{
+			protocol.TextDocumentContentChangeEvent{
+				Range: &protocol.Range{
+					Start: protocol.Position{Line: 8, Character: 4},
+					End:   protocol.Position{Line: 8, Character: 4},
+				},
+				Text: "tures",
+			},
+		},
+	})
+	expect.NoErr(t, err)
+
+	expect.Equals(t, 2, len(published))
+	expect.Equals(t, 0, len(published[1].Diagnostics))
+
+	err = documentDidClose(ctx, &protocol.DidCloseTextDocumentParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+	})
+	expect.NoErr(t, err)
+	expect.Equals(t, 3, len(published))
+	expect.Equals(t, 0, len(published[2].Diagnostics))
+}
+
+func TestRequestBodyDiagnostics(t *testing.T) {
+	conf.OpenapiDefPath = "./fixtures/petstore.yaml"
+	conf.Variables = []string{"url"}
+	parseOpenapi()
+	t.Cleanup(func() {
+		conf.OpenapiDefPath = ""
+		conf.Variables = nil
+		specs = openapi.Specs{}
+	})
+
+	uri := "./fixtures/test_captures.hurl"
+	contents, err := os.ReadFile(uri)
+	expect.NoErr(t, err)
+
+	published := []protocol.PublishDiagnosticsParams{}
+	err = documentDidOpen(testContext(&published), &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: uri, Version: 1, Text: string(contents)},
+	})
+	expect.NoErr(t, err)
+
+	diags := published[0].Diagnostics
+	expect.Equals(t, 2, len(diags))
+	for i, name := range []string{"name", "photoUrls"} {
+		expect.Equals(t, fmt.Sprintf("missing required property %q in $", name), diags[i].Message)
+		expect.Equals(t, protocol.Range{
+			Start: protocol.Position{Line: 11, Character: 0},
+			End:   protocol.Position{Line: 11, Character: 1},
+		}, diags[i].Range)
+	}
+}
+
+func TestMultipleSpecs(t *testing.T) {
+	conf.Openapi = []specConfig{
+		{Path: "./fixtures/petstore.yaml", BaseURL: "{{store_url}}"},
+		{Path: "./fixtures/petstore_swagger.yaml", Files: "fixtures/*.hurl"},
+	}
+	conf.Variables = []string{"store_url", "url"}
+	parseOpenapi()
+	t.Cleanup(func() {
+		conf.Openapi = nil
+		conf.Variables = nil
+		specs = openapi.Specs{}
+	})
+
+	text := "GET {{store_url}}/pet/findByStatus\n\nGET {{url}}/v1/pets/1\n\nGET {{url}}/v1/cats\n"
+	open := func(uri string) []protocol.Diagnostic {
+		published := []protocol.PublishDiagnosticsParams{}
+		err := documentDidOpen(testContext(&published), &protocol.DidOpenTextDocumentParams{
+			TextDocument: protocol.TextDocumentItem{URI: uri, Version: 1, Text: text},
+		})
+		expect.NoErr(t, err)
+
+		return published[0].Diagnostics
+	}
+
+	t.Run("spec of the base url and of the glob", func(t *testing.T) {
+		uri := "./fixtures/multi.hurl"
+		diags := open(uri)
+		expect.Equals(t, 1, len(diags))
+		expect.Equals(t, "{{url}}/v1/cats isn't a path in the openapi spec", diags[0].Message)
+
+		h, err := documentHover(testContext(nil), &protocol.HoverParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+				Position:     protocol.Position{Line: 2, Character: 1},
+			},
+		})
+		expect.NoErr(t, err)
+		expect.Equals(t, true, strings.HasPrefix(h.Contents.(protocol.MarkupContent).Value, "**GET /pets/{petId}**"))
+	})
+
+	t.Run("files outside of the glob", func(t *testing.T) {
+		uri := "./multi.hurl"
+		expect.Equals(t, 0, len(open(uri)))
+
+		hoverAt := func(line protocol.UInteger) *protocol.Hover {
+			h, err := documentHover(testContext(nil), &protocol.HoverParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+					Position:     protocol.Position{Line: line, Character: 1},
+				},
+			})
+			expect.NoErr(t, err)
+
+			return h
+		}
+
+		// the base url still has a spec
+		expect.Equals(t, true, strings.HasPrefix(hoverAt(0).Contents.(protocol.MarkupContent).Value, "**GET /pet/findByStatus**"))
+		// no spec documents the request
+		expect.Equals(t, (*protocol.Hover)(nil), hoverAt(2))
+	})
+}
+
+func TestUndefinedVariables(t *testing.T) {
+	uri := "./fixtures/test_captures.hurl"
+	contents, err := os.ReadFile(uri)
+	expect.NoErr(t, err)
+	open := func(ctx *glsp.Context) {
+		err = documentDidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+			TextDocument: protocol.TextDocumentItem{URI: uri, Version: 1, Text: string(contents)},
+		})
+		expect.NoErr(t, err)
+	}
+
+	t.Cleanup(func() {
+		conf.VariablesFile = ""
+		fileVarNames = []string{}
+	})
+
+	t.Run("without variables file", func(t *testing.T) {
+		published := []protocol.PublishDiagnosticsParams{}
+		open(testContext(&published))
+
+		diags := published[0].Diagnostics
+		expect.Equals(t, 3, len(diags))
+		for i, line := range []protocol.UInteger{0, 5, 10} {
+			expect.Equals(t, `undefined variable "url", it isn't captured before this line or declared in the variables config`, diags[i].Message)
+			expect.Equals(t, protocol.DiagnosticSeverityWarning, *diags[i].Severity)
+			expect.Equals(t, protocol.Range{
+				Start: protocol.Position{Line: line, Character: 6},
+				End:   protocol.Position{Line: line, Character: 9},
+			}, diags[i].Range)
+		}
+	})
+
+	t.Run("with variables file", func(t *testing.T) {
+		conf.VariablesFile = "./fixtures/vars.env"
+		parseVariablesFile()
+
+		published := []protocol.PublishDiagnosticsParams{}
+		open(testContext(&published))
+
+		expect.Equals(t, 0, len(published[0].Diagnostics))
+	})
+}
+
+func TestDefinition(t *testing.T) {
+	uri := "./fixtures/test_captures.hurl"
+	openFixture(t, uri)
+
+	def := func(line, char protocol.UInteger) any {
+		loc, err := definition(testContext(nil), &protocol.DefinitionParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+				Position:     protocol.Position{Line: line, Character: char},
+			},
+		})
+		expect.NoErr(t, err)
+
+		return loc
+	}
+
+	// GET {{url}}/pet/{{id}}
+	expected := protocol.Location{
+		URI: uri,
+		Range: protocol.Range{
+			Start: protocol.Position{Line: 3, Character: 0},
+			End:   protocol.Position{Line: 3, Character: 2},
+		},
+	}
+	expect.Equals(t, expected, def(5, 18))
+	expect.Equals(t, expected, def(5, 20))
+
+	// not on a template
+	expect.Equals(t, nil, def(5, 1))
+	// url is never captured
+	expect.Equals(t, nil, def(5, 7))
+}
+
+func TestReferencesAndRename(t *testing.T) {
+	uri := "./fixtures/test_captures.hurl"
+	openFixture(t, uri)
+	pos := func(line, char protocol.UInteger) protocol.TextDocumentPositionParams {
+		return protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Position:     protocol.Position{Line: line, Character: char},
+		}
+	}
+	rng := func(line, start, end protocol.UInteger) protocol.Range {
+		return protocol.Range{
+			Start: protocol.Position{Line: line, Character: start},
+			End:   protocol.Position{Line: line, Character: end},
+		}
+	}
+
+	t.Run("references", func(t *testing.T) {
+		locs, err := references(testContext(nil), &protocol.ReferenceParams{
+			TextDocumentPositionParams: pos(5, 19),
+			Context:                    protocol.ReferenceContext{IncludeDeclaration: true},
+		})
+		expect.NoErr(t, err)
+		expect.Equals(t, []protocol.Location{
+			{URI: uri, Range: rng(3, 0, 2)},
+			{URI: uri, Range: rng(5, 18, 20)},
+		}, locs)
+
+		// from the capture, without the declaration
+		locs, err = references(testContext(nil), &protocol.ReferenceParams{
+			TextDocumentPositionParams: pos(3, 1),
+		})
+		expect.NoErr(t, err)
+		expect.Equals(t, []protocol.Location{{URI: uri, Range: rng(5, 18, 20)}}, locs)
+	})
+
+	t.Run("prepare rename", func(t *testing.T) {
+		r, err := prepareRename(testContext(nil), &protocol.PrepareRenameParams{TextDocumentPositionParams: pos(5, 19)})
+		expect.NoErr(t, err)
+		expect.Equals(t, protocol.RangeWithPlaceholder{Range: rng(5, 18, 20), Placeholder: "id"}, r)
+
+		// url isn't captured in the file
+		_, err = prepareRename(testContext(nil), &protocol.PrepareRenameParams{TextDocumentPositionParams: pos(5, 7)})
+		expect.Err(t, err)
+
+		r, err = prepareRename(testContext(nil), &protocol.PrepareRenameParams{TextDocumentPositionParams: pos(5, 0)})
+		expect.NoErr(t, err)
+		expect.Equals(t, nil, r)
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		edit, err := rename(testContext(nil), &protocol.RenameParams{TextDocumentPositionParams: pos(3, 0), NewName: "pet_id"})
+		expect.NoErr(t, err)
+		expect.Equals(t, &protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+				uri: {
+					{Range: rng(3, 0, 2), NewText: "pet_id"},
+					{Range: rng(5, 18, 20), NewText: "pet_id"},
+				},
+			},
+		}, edit)
+
+		_, err = rename(testContext(nil), &protocol.RenameParams{TextDocumentPositionParams: pos(3, 0), NewName: "name"})
+		expect.Err(t, err)
+		expect.ErrContains(t, "already exists", err)
+
+		_, err = rename(testContext(nil), &protocol.RenameParams{TextDocumentPositionParams: pos(3, 0), NewName: "pet id"})
+		expect.Err(t, err)
+		expect.ErrContains(t, "not a valid variable name", err)
+	})
+}
+
+func TestMultibytePositions(t *testing.T) {
+	uri := "file:///multibyte.hurl"
+	err := documentDidOpen(testContext(nil), &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: uri, Version: 1, Text: "GET http://a\nHTTP 200\n[Captures]\nid: jsonpath \"$.id\"\n\nPOST http://a/😀😀😀😀/{{id}}\n"},
+	})
+	expect.NoErr(t, err)
+
+	// each 😀 is 4 bytes but 2 UTF-16 code units, so {{id}} is at bytes 33 to
+	// 35 and characters 25 to 27
+	at := protocol.TextDocumentPositionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Position:     protocol.Position{Line: 5, Character: 26},
+	}
+	use := protocol.Range{
+		Start: protocol.Position{Line: 5, Character: 25},
+		End:   protocol.Position{Line: 5, Character: 27},
+	}
+	capture := protocol.Range{
+		Start: protocol.Position{Line: 3, Character: 0},
+		End:   protocol.Position{Line: 3, Character: 2},
+	}
+
+	loc, err := definition(testContext(nil), &protocol.DefinitionParams{TextDocumentPositionParams: at})
+	expect.NoErr(t, err)
+	expect.Equals(t, any(protocol.Location{URI: uri, Range: capture}), loc)
+
+	locs, err := references(testContext(nil), &protocol.ReferenceParams{TextDocumentPositionParams: at})
+	expect.NoErr(t, err)
+	expect.Equals(t, []protocol.Location{{URI: uri, Range: use}}, locs)
+
+	edit, err := rename(testContext(nil), &protocol.RenameParams{TextDocumentPositionParams: at, NewName: "pet_id"})
+	expect.NoErr(t, err)
+	expect.Equals(t, []protocol.TextEdit{
+		{Range: capture, NewText: "pet_id"},
+		{Range: use, NewText: "pet_id"},
+	}, edit.Changes[uri])
+
+	h, err := documentHover(testContext(nil), &protocol.HoverParams{TextDocumentPositionParams: at})
+	expect.NoErr(t, err)
+	expect.Equals(t, true, strings.HasPrefix(h.Contents.(protocol.MarkupContent).Value, "**id** _(variable)_"))
+}
+
+func TestHover(t *testing.T) {
+	uri := "./fixtures/test_captures.hurl"
+	openFixture(t, uri)
+	hoverAt := func(line, char protocol.UInteger) *protocol.Hover {
+		h, err := documentHover(testContext(nil), &protocol.HoverParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+				Position:     protocol.Position{Line: line, Character: char},
+			},
+		})
+		expect.NoErr(t, err)
+
+		return h
+	}
+
+	t.Cleanup(func() {
+		conf.OpenapiDefPath = ""
+		specs = openapi.Specs{}
+	})
+
+	t.Run("query", func(t *testing.T) {
+		h := hoverAt(3, 5)
+		content := h.Contents.(protocol.MarkupContent)
+		expect.Equals(t, protocol.MarkupKindMarkdown, content.Kind)
+		expect.Equals(t, true, strings.HasPrefix(content.Value, "**jsonpath** _(query)_"))
+	})
+
+	t.Run("request line without openapi", func(t *testing.T) {
+		expect.Equals(t, (*protocol.Hover)(nil), hoverAt(0, 1))
+	})
+
+	t.Run("request line with openapi", func(t *testing.T) {
+		conf.OpenapiDefPath = "./fixtures/petstore.yaml"
+		parseOpenapi()
+
+		content := hoverAt(0, 1).Contents.(protocol.MarkupContent)
+		expect.Equals(t, "**GET /pet/findByStatus**\n\n"+
+			"Finds Pets by status.\n\n"+
+			"Multiple status values can be provided with comma separated strings.\n\n"+
+			"**Parameters**\n\n"+
+			"- `status` (query, string): Status values that need to be considered for filter\n\n"+
+			"**Responses**\n\n"+
+			"- `200`: successful operation\n"+
+			"- `400`: Invalid status value\n"+
+			"- `default`: Unexpected error", content.Value)
+	})
+}
+
+func TestFormatting(t *testing.T) {
+	uri := "file:///format.hurl"
+	err := documentDidOpen(testContext(nil), &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: uri, Version: 1, Text: "GET  /a\n\n\nPOST  /b\n{\"a\":1}"},
+	})
+	expect.NoErr(t, err)
+	doc := protocol.TextDocumentIdentifier{URI: uri}
+
+	t.Run("document", func(t *testing.T) {
+		edits, err := formatting(testContext(nil), &protocol.DocumentFormattingParams{
+			TextDocument: doc,
+			Options:      protocol.FormattingOptions{"tabSize": float64(2), "insertSpaces": true},
+		})
+		expect.NoErr(t, err)
+
+		expect.Equals(t, []protocol.TextEdit{{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: 0, Character: 0},
+				End:   protocol.Position{Line: 4, Character: 7},
+			},
+			NewText: "GET /a\n\nPOST /b\n{\n  \"a\": 1\n}\n",
+		}}, edits)
+	})
+
+	t.Run("range", func(t *testing.T) {
+		edits, err := rangeFormatting(testContext(nil), &protocol.DocumentRangeFormattingParams{
+			TextDocument: doc,
+			Range: protocol.Range{
+				Start: protocol.Position{Line: 0, Character: 0},
+				End:   protocol.Position{Line: 0, Character: 2},
+			},
+			Options: protocol.FormattingOptions{"tabSize": float64(4), "insertSpaces": false},
+		})
+		expect.NoErr(t, err)
+
+		expect.Equals(t, 1, len(edits))
+		expect.Equals(t, "GET /a\n", edits[0].NewText)
+		expect.Equals(t, protocol.Position{Line: 0, Character: 0}, edits[0].Range.Start)
+	})
+}
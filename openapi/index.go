@@ -0,0 +1,166 @@
+package openapi
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// index is built once by Parse so that looking up an operation doesn't decode
+// any JSON or visit every path of the spec
+type index struct {
+	root *trieNode
+	// paths are the paths of the spec, sorted
+	paths []string
+	items map[string]pathItem
+}
+
+// pathItem holds the decoded operations of a path in the spec
+type pathItem struct {
+	// malformed is true when the path item itself can't be decoded
+	malformed bool
+	// methods are the documented methods in lower case, in the usual order
+	methods []string
+	// ops are keyed by lower case method, the detail is nil when the
+	// operation can't be decoded
+	ops map[string]*OpDetail
+}
+
+// trieNode is a segment of the paths of the spec, children are tried in the
+// order of the precedence of their segments
+type trieNode struct {
+	literals map[string]*trieNode
+	// literalKeys are the keys of literals, sorted
+	literalKeys []string
+	params      []paramChild
+	// path is the path in the spec that ends at this node, empty when none does
+	path string
+}
+
+type paramChild struct {
+	segment
+	node *trieNode
+}
+
+func newTrieNode() *trieNode {
+	return &trieNode{literals: make(map[string]*trieNode)}
+}
+
+func (n *trieNode) insert(path string, segments []segment) {
+	for _, seg := range segments {
+		n = n.child(seg)
+	}
+	n.path = path
+}
+
+func (n *trieNode) child(seg segment) *trieNode {
+	if seg.param == nil {
+		if child, ok := n.literals[seg.literal]; ok {
+			return child
+		}
+
+		child := newTrieNode()
+		n.literals[seg.literal] = child
+		i, _ := slices.BinarySearch(n.literalKeys, seg.literal)
+		n.literalKeys = slices.Insert(n.literalKeys, i, seg.literal)
+
+		return child
+	}
+
+	for _, p := range n.params {
+		if p.literal == seg.literal {
+			return p.node
+		}
+	}
+
+	child := paramChild{segment: seg, node: newTrieNode()}
+	i, _ := slices.BinarySearchFunc(n.params, seg.literal, func(p paramChild, literal string) int {
+		return strings.Compare(p.literal, literal)
+	})
+	n.params = slices.Insert(n.params, i, child)
+
+	return child.node
+}
+
+// find returns the path in the spec matching the parts of a request path.
+// Children are tried from the highest score to the lowest, so the first
+// match wins over later ones segment by segment from the left.
+func (n *trieNode) find(parts []string) (string, bool) {
+	if len(parts) == 0 {
+		return n.path, n.path != ""
+	}
+
+	part, rest := parts[0], parts[1:]
+	if child, ok := n.literals[part]; ok {
+		if path, ok := child.find(rest); ok {
+			return path, true
+		}
+	}
+
+	for _, p := range n.params {
+		if p.score(part) == scoreParam {
+			if path, ok := p.node.find(rest); ok {
+				return path, true
+			}
+		}
+	}
+
+	if !reHurlTemplate.MatchString(part) {
+		return "", false
+	}
+
+	for _, key := range n.literalKeys {
+		if (segment{literal: key}).score(part) == scoreTemplate {
+			if path, ok := n.literals[key].find(rest); ok {
+				return path, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// buildIndex decodes every operation of the spec and resolves their $refs
+func (o OAI) buildIndex() *index {
+	idx := &index{
+		root:  newTrieNode(),
+		paths: make([]string, 0, len(o.Paths)),
+		items: make(map[string]pathItem, len(o.Paths)),
+	}
+
+	for path, raw := range o.Paths {
+		idx.paths = append(idx.paths, path)
+		idx.root.insert(path, parseSegments(path))
+		idx.items[path] = o.decodePathItem(raw)
+	}
+	slices.Sort(idx.paths)
+
+	return idx
+}
+
+func (o OAI) decodePathItem(raw json.RawMessage) pathItem {
+	content, err := o.pathContent(raw)
+	if err != nil {
+		return pathItem{malformed: true}
+	}
+
+	// parameters of the path apply to all of its operations
+	var shared OpParams
+	if raw, ok := content["parameters"]; ok {
+		_ = json.Unmarshal(raw, &shared)
+	}
+
+	item := pathItem{methods: documentedMethods(content), ops: make(map[string]*OpDetail)}
+	for _, method := range item.methods {
+		var detail OpDetail
+		if err := json.Unmarshal(content[method], &detail); err != nil {
+			item.ops[method] = nil
+			continue
+		}
+
+		resolved := o.resolveDetail(detail, shared)
+		item.ops[method] = &resolved
+	}
+
+	return item
+}
@@ -0,0 +1,186 @@
+package openapi
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type Server struct {
+	URL       string                    `json:"url"`
+	Variables map[string]ServerVariable `json:"variables"`
+}
+
+type ServerVariable struct {
+	Default string   `json:"default"`
+	Enum    []string `json:"enum"`
+}
+
+// reParam matches a {name} in a server URL or a path of the spec
+var reParam = regexp.MustCompile(`\{[^}]*\}`)
+
+// reQuotedParam matches a {param} after regexp.QuoteMeta
+var reQuotedParam = regexp.MustCompile(`\\\{[^}]*\\\}`)
+
+// pathRegexp returns a regexp matching the path of the server's URL with its
+// variables replaced by their values, nil when the URL has no path
+func (s Server) pathRegexp() *regexp.Regexp {
+	path := s.URL
+	if i := strings.Index(path, "://"); i >= 0 {
+		path = path[i+len("://"):]
+		j := strings.Index(path, "/")
+		if j < 0 {
+			return nil
+		}
+		path = path[j:]
+	}
+
+	path = strings.TrimRight(path, "/")
+	if path == "" {
+		return nil
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	last := 0
+	for _, loc := range reParam.FindAllStringIndex(path, -1) {
+		expr.WriteString(regexp.QuoteMeta(path[last:loc[0]]))
+		expr.WriteString(s.variablePattern(path[loc[0]+1 : loc[1]-1]))
+		last = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(path[last:]))
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil
+	}
+
+	return re
+}
+
+// variablePattern matches any of the values of a server variable
+func (s Server) variablePattern(name string) string {
+	v := s.Variables[name]
+	values := slices.Clone(v.Enum)
+	if v.Default != "" && !slices.Contains(values, v.Default) {
+		values = append(values, v.Default)
+	}
+
+	if len(values) == 0 {
+		return `[^/]+`
+	}
+
+	for i, value := range values {
+		values[i] = regexp.QuoteMeta(value)
+	}
+
+	return "(?:" + strings.Join(values, "|") + ")"
+}
+
+// TargetPath is like SplitTarget, but the path of a server in the spec at the
+// start of the path is moved to the base, so the path can be found in the spec.
+func (o OAI) TargetPath(target string) (base, path, query string) {
+	base, path, query = SplitTarget(target)
+	longest := ""
+	for _, re := range o.serverPaths {
+		prefix := re.FindString(path)
+		if len(prefix) > len(longest) && (len(prefix) == len(path) || path[len(prefix)] == '/') {
+			longest = prefix
+		}
+	}
+
+	return base + longest, path[len(longest):], query
+}
+
+// segment is a part of a path in the spec between slashes
+type segment struct {
+	literal string
+	// param matches the segment when it has parameters like {id} or {id}.json
+	param *regexp.Regexp
+}
+
+func parseSegments(path string) []segment {
+	parts := splitPath(path)
+	segments := make([]segment, 0, len(parts))
+	for _, part := range parts {
+		if !reParam.MatchString(part) {
+			segments = append(segments, segment{literal: part})
+			continue
+		}
+
+		expr := "^" + regexp.QuoteMeta(part) + "$"
+		expr = reQuotedParam.ReplaceAllString(expr, `[^/]+`)
+		segments = append(segments, segment{literal: part, param: regexp.MustCompile(expr)})
+	}
+
+	return segments
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return []string{}
+	}
+
+	return strings.Split(path, "/")
+}
+
+var reHurlTemplate = regexp.MustCompile(`\{\{[^}]*\}\}`)
+
+// The scores of a request segment matching a segment in the spec, the
+// higher the more specific
+const (
+	// scoreTemplate is a {{template}} that could be a literal in the spec
+	scoreTemplate = iota + 1
+	scoreParam
+	scoreLiteral
+)
+
+// score returns how well the segment of a request matches the segment in the
+// spec, 0 when it doesn't
+func (s segment) score(part string) int {
+	templated := reHurlTemplate.MatchString(part)
+	switch {
+	case s.param == nil && s.literal == part:
+		return scoreLiteral
+	case s.param != nil && (templated || s.param.MatchString(part)):
+		return scoreParam
+	case s.param == nil && templated:
+		if ok, _ := regexp.MatchString(templatePattern(part), s.literal); ok {
+			return scoreTemplate
+		}
+	}
+
+	return 0
+}
+
+// templatePattern returns a pattern matching the text a segment with
+// {{templates}} could be once they are replaced by anything
+func templatePattern(part string) string {
+	var expr strings.Builder
+	expr.WriteString("^")
+	last := 0
+	for _, loc := range reHurlTemplate.FindAllStringIndex(part, -1) {
+		expr.WriteString(regexp.QuoteMeta(part[last:loc[0]]))
+		expr.WriteString(".+")
+		last = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(part[last:]))
+	expr.WriteString("$")
+
+	return expr.String()
+}
+
+// Match returns the path in the spec that documents the target. The target
+// is matched segment by segment after the base url and the query string are
+// removed. When several paths match, concrete segments win over templated
+// ones from left to right, so /pets/mine is preferred over /pets/{id}.
+func (o OAI) Match(target string) (string, bool) {
+	if o.index == nil {
+		return "", false
+	}
+
+	_, path, _ := o.TargetPath(target)
+
+	return o.index.root.find(splitPath(path))
+}
@@ -4,39 +4,71 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/goccy/go-yaml"
 )
 
-var (
-	paramRe = regexp.MustCompile(`\{\w*\}`)
-)
-
 type OAI struct {
-	Paths       map[string]json.RawMessage `json:"paths"`
-	pathRegexps map[string]*regexp.Regexp
+	Paths   map[string]json.RawMessage `json:"paths"`
+	Servers []Server                   `json:"servers"`
+	// index is nil until Parse builds it
+	index *index
+	// serverPaths match the paths of the servers' URLs at the start of a path
+	serverPaths []*regexp.Regexp
+	// raw is the whole document, $refs are resolved in it
+	raw  json.RawMessage
+	refs *refCache
 }
 
+// PathList returns the paths of the spec in alphabetical order
 func (o OAI) PathList() []string {
-	if len(o.Paths) == 0 {
+	if o.index == nil {
 		return []string{}
 	}
 
-	paths := make([]string, 0, len(o.Paths))
+	return slices.Clone(o.index.paths)
+}
 
-	for p := range o.Paths {
-		paths = append(paths, p)
+type OpDetail struct {
+	Summary     string                `json:"summary"`
+	Description string                `json:"description"`
+	Parameters  OpParams              `json:"parameters"`
+	RequestBody *RequestBody          `json:"requestBody"`
+	Responses   map[string]OpResponse `json:"responses"`
+}
+
+type RequestBody struct {
+	Ref         string               `json:"$ref"`
+	Description string               `json:"description"`
+	Required    bool                 `json:"required"`
+	Content     map[string]MediaType `json:"content"`
+}
 
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// JSONSchema returns the schema of the JSON content of the body, nil when the
+// body can't be JSON.
+func (rb RequestBody) JSONSchema() *Schema {
+	if mt, ok := rb.Content["application/json"]; ok {
+		return mt.Schema
+	}
+
+	for name, mt := range rb.Content {
+		if strings.HasSuffix(strings.Split(name, ";")[0], "+json") {
+			return mt.Schema
+		}
 	}
 
-	return paths
+	return nil
 }
 
-type OpDetail struct {
-	Summary     string   `json:"summary"`
-	Description string   `json:"description"`
-	Parameters  OpParams `json:"parameters"`
+type OpResponse struct {
+	Ref         string `json:"$ref"`
+	Description string `json:"description"`
 }
 
 type OpParams []OpParam
@@ -51,13 +83,47 @@ func (ops OpParams) ToDocMap() map[string]string {
 }
 
 type OpParam struct {
-	Name   string `json:"name"`
-	In     string `json:"in"`
-	Schema Schema `json:"schema"`
+	Ref         string `json:"$ref"`
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+	Schema      Schema `json:"schema"`
 }
 
 type Schema struct {
-	Type string `json:"type"`
+	Ref        string             `json:"$ref"`
+	Type       string             `json:"type"`
+	Format     string             `json:"format"`
+	Nullable   bool               `json:"nullable"`
+	Properties map[string]*Schema `json:"properties"`
+	Required   []string           `json:"required"`
+	// AdditionalProperties is either a boolean or a schema
+	AdditionalProperties json.RawMessage `json:"additionalProperties"`
+	Items                *Schema         `json:"items"`
+	Enum                 []any           `json:"enum"`
+	AllOf                []*Schema       `json:"allOf"`
+}
+
+// AllowsAdditional reports whether an object can have properties that aren't
+// in Properties, which is the default.
+func (s Schema) AllowsAdditional() bool {
+	return strings.TrimSpace(string(s.AdditionalProperties)) != "false"
+}
+
+// Resolve returns the schema its $ref points to, or the schema itself when it
+// isn't a reference. It returns nil when the reference can't be resolved.
+func (o OAI) Resolve(s *Schema) *Schema {
+	if s == nil {
+		return nil
+	}
+
+	resolved, err := resolve(o, s.Ref, s)
+	if err != nil {
+		return nil
+	}
+
+	return resolved
 }
 
 type Op struct {
@@ -69,32 +135,8 @@ type Op struct {
 const undocumentedOpSummary = "Operation not documented"
 
 func (o OAI) GetOp(method, path string) Op {
-	// We look for the longest possible match to get the most specific match
-	// So if there is /pets and /pets/{id}, /pets/1 will match both but we would
-	// want the /pets/{id} path so that's the one we get the documentation of.
-	longestMatching := 0
-	pathInSpec := ""
-	var rawPathContent json.RawMessage
-	for pInSpec, content := range o.Paths {
-		if len(pInSpec) < longestMatching {
-			continue
-		}
-
-		reg, ok := o.pathRegexps[pInSpec]
-		if !ok || reg == nil {
-			continue
-		}
-
-		match := reg.MatchString(path)
-		// println("reg", reg.String(), "path", path, "match", match)
-		if match {
-			rawPathContent = content
-			longestMatching = len(pInSpec)
-			pathInSpec = pInSpec
-		}
-	}
-
-	if longestMatching == 0 {
+	pathInSpec, ok := o.Match(path)
+	if !ok {
 		return Op{
 			Path:   path,
 			Method: method,
@@ -110,10 +152,8 @@ func (o OAI) GetOp(method, path string) Op {
 		Method: method,
 	}
 
-	const httpMethodsCount = 9
-	pathContent := make(map[string]OpDetail, httpMethodsCount)
-
-	if err := json.Unmarshal(rawPathContent, &pathContent); err != nil {
+	item := o.index.items[pathInSpec]
+	if item.malformed {
 		op.Detail = OpDetail{
 			Summary:     undocumentedOpSummary,
 			Description: "Documentation of is malformed json/yaml",
@@ -122,7 +162,7 @@ func (o OAI) GetOp(method, path string) Op {
 		return op
 	}
 
-	opDetail, ok := pathContent[strings.ToLower(method)]
+	detail, ok := item.ops[strings.ToLower(method)]
 
 	if !ok {
 		op.Detail = OpDetail{
@@ -131,24 +171,47 @@ func (o OAI) GetOp(method, path string) Op {
 				"%s: undocumented method %s. The following methods are documented for this path %s.",
 				pathInSpec,
 				strings.ToUpper(method),
-				strings.ToUpper(strings.Join(mapKeys(pathContent), ",")),
+				strings.ToUpper(strings.Join(item.methods, ",")),
 			),
 		}
 
 		return op
 	}
 
-	op.Detail = opDetail
+	if detail == nil {
+		op.Detail = OpDetail{
+			Summary:     undocumentedOpSummary,
+			Description: "Documentation of is malformed json/yaml",
+		}
+
+		return op
+	}
+
+	op.Detail = *detail
 	return op
 }
 
-func mapKeys[T any](m map[string]T) []string {
-	keys := make([]string, 0, len(m))
-	for key := range m {
-		keys = append(keys, key)
+// Methods returns the methods documented for a path in the spec, in lower case
+func (o OAI) Methods(pathInSpec string) []string {
+	if o.index == nil {
+		return []string{}
+	}
+
+	return slices.Clone(o.index.items[pathInSpec].methods)
+}
+
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// documentedMethods returns the methods of a path item in the usual order
+func documentedMethods(pathContent map[string]json.RawMessage) []string {
+	methods := make([]string, 0, len(httpMethods))
+	for _, method := range httpMethods {
+		if _, ok := pathContent[method]; ok {
+			methods = append(methods, method)
+		}
 	}
 
-	return keys
+	return methods
 }
 
 func Parse(ftype string, fContents []byte) (OAI, error) {
@@ -168,22 +231,27 @@ func Parse(ftype string, fContents []byte) (OAI, error) {
 		fContents = jsonContent
 	}
 
+	if isSwagger2(fContents) {
+		converted, err := fromSwagger2(fContents)
+		if err != nil {
+			return oai, fail(err)
+		}
+		fContents = converted
+	}
+
 	if err := json.Unmarshal(fContents, &oai); err != nil {
 		return oai, fail(err)
 	}
+	oai.raw = fContents
+	oai.refs = newRefCache()
 
-	pRes := make(map[string]*regexp.Regexp, len(oai.Paths)*2)
-	for p := range oai.Paths {
-		re, err := regexp.Compile(string(paramRe.ReplaceAll([]byte(p), []byte(`[a-zA-Z0-9_{}]+`))))
-		if err != nil {
-			println("err", fail(err).Error())
-			continue
+	for _, server := range oai.Servers {
+		if re := server.pathRegexp(); re != nil {
+			oai.serverPaths = append(oai.serverPaths, re)
 		}
-
-		pRes[p] = re
 	}
 
-	oai.pathRegexps = pRes
+	oai.index = oai.buildIndex()
 
 	return oai, nil
 }
@@ -1,8 +1,10 @@
 package openapi_test
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/ethancarlsson/hurl-lsp/expect"
@@ -56,6 +58,38 @@ func TestGetOp(t *testing.T) {
 			expectSummary: "Finds Pets by tags.",
 			expectDesc:    "Multiple tags can be provided with comma separated strings. Use tag1, tag2, tag3 for testing.",
 		},
+		{
+			method:        "get",
+			path:          "{{url}}/api/v3/pet/{{id}}",
+			expectMethod:  "get",
+			expectPath:    "/pet/{petId}",
+			expectSummary: "Find pet by ID.",
+			expectDesc:    "Returns a single pet.",
+		},
+		{
+			method:        "get",
+			path:          "https://petstore3.swagger.io/api/v3/pet/findByStatus?status=sold",
+			expectMethod:  "get",
+			expectPath:    "/pet/findByStatus",
+			expectSummary: "Finds Pets by status.",
+			expectDesc:    "Multiple status values can be provided with comma separated strings.",
+		},
+		{
+			method:        "get",
+			path:          "{{url}}/api/v3/store/{{id}}",
+			expectMethod:  "get",
+			expectPath:    "/store/inventory",
+			expectSummary: "Returns pet inventories by status.",
+			expectDesc:    "Returns a map of status codes to quantities.",
+		},
+		{
+			method:        "get",
+			path:          "{{url}}/pets/findByStatus",
+			expectMethod:  "get",
+			expectPath:    "{{url}}/pets/findByStatus",
+			expectSummary: "Operation not documented",
+			expectDesc:    "Path not found in provided openapi spec",
+		},
 	}
 
 	for _, tt := range tests {
@@ -74,3 +108,361 @@ func TestGetOp(t *testing.T) {
 		})
 	}
 }
+
+func TestDeref(t *testing.T) {
+	spec := `{
+		"paths": {
+			"/pets/{id}": {
+				"parameters": [{"$ref": "#/components/parameters/id"}],
+				"get": {
+					"parameters": [{"$ref": "#/components/parameters/limit"}, {"$ref": "#/components/parameters/missing"}],
+					"responses": {
+						"200": {"$ref": "#/components/responses/Pet"},
+						"400": {"$ref": "#/components/responses/Loop"}
+					}
+				},
+				"post": {"requestBody": {"$ref": "#/components/requestBodies/Pet"}}
+			}
+		},
+		"components": {
+			"parameters": {
+				"id": {"name": "id", "in": "path", "required": true, "schema": {"$ref": "#/components/schemas/Id"}},
+				"limit": {"name": "limit", "in": "query", "schema": {"type": "integer"}}
+			},
+			"responses": {
+				"Pet": {"$ref": "#/components/responses/Found"},
+				"Found": {"description": "the pet"},
+				"Loop": {"$ref": "#/components/responses/Loop"}
+			},
+			"requestBodies": {
+				"Pet": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}
+			},
+			"schemas": {
+				"Id": {"type": "string"},
+				"Pet": {"type": "object", "required": ["name"]},
+				"A": {"$ref": "#/components/schemas/B"},
+				"B": {"$ref": "#/components/schemas/A"}
+			}
+		}
+	}`
+	oai, err := openapi.Parse("json", []byte(spec))
+	expect.NoErr(t, err)
+
+	t.Run("get", func(t *testing.T) {
+		op := oai.GetOp("get", "/pets/1")
+		expect.Equals(t, openapi.OpParams{
+			{Name: "limit", In: "query", Schema: openapi.Schema{Type: "integer"}},
+			{Name: "id", In: "path", Required: true, Schema: openapi.Schema{Type: "string"}},
+		}, op.Detail.Parameters)
+		expect.Equals(t, map[string]openapi.OpResponse{"200": {Description: "the pet"}}, op.Detail.Responses)
+	})
+
+	t.Run("request body", func(t *testing.T) {
+		op := oai.GetOp("post", "/pets/1")
+		expect.Equals(t, []string{"name"}, op.Detail.RequestBody.JSONSchema().Required)
+	})
+
+	t.Run("escaped pointer", func(t *testing.T) {
+		var name string
+		expect.NoErr(t, oai.Deref("#/paths/~1pets~1{id}/get/parameters/0/$ref", &name))
+		expect.Equals(t, "#/components/parameters/limit", name)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		var s openapi.Schema
+		err := oai.Deref("#/components/schemas/A", &s)
+		expect.Equals(t, true, errors.Is(err, openapi.ErrRefCycle))
+		expect.Equals(t, (*openapi.Schema)(nil), oai.Resolve(&openapi.Schema{Ref: "#/components/schemas/A"}))
+	})
+
+	t.Run("unresolvable", func(t *testing.T) {
+		var s openapi.Schema
+		expect.ErrContains(t, "it isn't in the document", oai.Deref("#/components/schemas/Nope", &s))
+		expect.ErrContains(t, "only references within the document", oai.Deref("other.yaml#/Pet", &s))
+	})
+
+	t.Run("petstore", func(t *testing.T) {
+		contents, err := os.ReadFile("../fixtures/petstore.yaml")
+		expect.NoErr(t, err)
+		oai, err := openapi.Parse("yaml", contents)
+		expect.NoErr(t, err)
+
+		op := oai.GetOp("post", "/user/createWithList")
+		schema := op.Detail.RequestBody.JSONSchema()
+		expect.Equals(t, "array", schema.Type)
+		expect.Equals(t, "object", oai.Resolve(schema.Items).Type)
+	})
+}
+
+func TestSplitTarget(t *testing.T) {
+	tests := []struct{ target, base, path, query string }{
+		{target: "/pets", path: "/pets"},
+		{target: "{{url}}/pets/1?limit=1", base: "{{url}}", path: "/pets/1", query: "?limit=1"},
+		{target: "https://example.com:8080/pets#top", base: "https://example.com:8080", path: "/pets", query: "#top"},
+		{target: "{{host}}{{prefix}}/pets", base: "{{host}}{{prefix}}", path: "/pets"},
+		{target: "{{url}}", base: "{{url}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			base, path, query := openapi.SplitTarget(tt.target)
+			expect.Equals(t, tt.base, base)
+			expect.Equals(t, tt.path, path)
+			expect.Equals(t, tt.query, query)
+		})
+	}
+}
+
+func TestFillPath(t *testing.T) {
+	tests := []struct {
+		path, pathInSpec, filled string
+		ok                       bool
+	}{
+		{path: "/pet/42/uploadImag", pathInSpec: "/pet/{petId}/uploadImage", filled: "/pet/42/uploadImage", ok: true},
+		{path: "/pets/{{id}}/photo/1.jpg", pathInSpec: "/pets/{id}/photos/{photo}.jpg", filled: "/pets/{{id}}/photos/1.jpg", ok: true},
+		{path: "/stor/inventry", pathInSpec: "/store/inventory", filled: "/store/inventory", ok: true},
+		{path: "/", pathInSpec: "/", filled: "/", ok: true},
+		{path: "/pet", pathInSpec: "/pet/{petId}", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path+" "+tt.pathInSpec, func(t *testing.T) {
+			filled, ok := openapi.FillPath(tt.path, tt.pathInSpec)
+			expect.Equals(t, tt.ok, ok)
+			expect.Equals(t, tt.filled, filled)
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	spec := `{
+		"servers": [
+			{"url": "https://{env}.example.com/{version}/", "variables": {
+				"env": {"default": "api"},
+				"version": {"default": "v1", "enum": ["v1", "v2"]}
+			}},
+			{"url": "/internal"}
+		],
+		"paths": {
+			"/pets": {},
+			"/pets/{id}": {},
+			"/pets/mine": {},
+			"/pets/{id}/photos/{photo}.jpg": {},
+			"/{kind}/mine": {}
+		}
+	}`
+	oai, err := openapi.Parse("json", []byte(spec))
+	expect.NoErr(t, err)
+
+	tests := []struct {
+		target, path string
+		ok           bool
+	}{
+		{target: "/pets", path: "/pets", ok: true},
+		{target: "/pets/", path: "/pets", ok: true},
+		{target: "{{url}}/v2/pets?limit=1", path: "/pets", ok: true},
+		{target: "https://api.example.com/v1/pets/mine", path: "/pets/mine", ok: true},
+		{target: "/internal/pets/1", path: "/pets/{id}", ok: true},
+		{target: "{{url}}/pets/{{id}}", path: "/pets/{id}", ok: true},
+		{target: "{{url}}/pets/{{id}}/photos/{{photo}}.jpg", path: "/pets/{id}/photos/{photo}.jpg", ok: true},
+		{target: "/cats/mine", path: "/{kind}/mine", ok: true},
+		{target: "{{url}}/pe{{ts}}", path: "/pets", ok: true},
+		{target: "{{url}}/{{kind}}/{{id}}/photos/1.jpg", path: "/pets/{id}/photos/{photo}.jpg", ok: true},
+		{target: "{{url}}/dogs{{x}}", ok: false},
+		{target: "/v3/pets", ok: false},
+		{target: "/pets/1/2", ok: false},
+		{target: "/petsmart", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			path, ok := oai.Match(tt.target)
+			expect.Equals(t, tt.ok, ok)
+			expect.Equals(t, tt.path, path)
+		})
+	}
+
+	t.Run("target path", func(t *testing.T) {
+		base, path, query := oai.TargetPath("{{url}}/v2/pets/1?x=1")
+		expect.Equals(t, "{{url}}/v2", base)
+		expect.Equals(t, "/pets/1", path)
+		expect.Equals(t, "?x=1", query)
+	})
+}
+
+func TestMalformedOperations(t *testing.T) {
+	spec := `{"paths": {
+		"/pets": {"get": "not an operation", "post": {"summary": "Add a pet"}},
+		"/cats": "not a path item"
+	}}`
+	oai, err := openapi.Parse("json", []byte(spec))
+	expect.NoErr(t, err)
+
+	expect.Equals(t, []string{"/cats", "/pets"}, oai.PathList())
+	expect.Equals(t, "Documentation of is malformed json/yaml", oai.GetOp("get", "/pets").Detail.Description)
+	expect.Equals(t, "Add a pet", oai.GetOp("post", "/pets").Detail.Summary)
+	expect.Equals(t, "Documentation of is malformed json/yaml", oai.GetOp("get", "/cats").Detail.Description)
+}
+
+// largeSpec returns a spec with n paths that share their first segments
+func largeSpec(n int) []byte {
+	var b strings.Builder
+	b.WriteString(`{"servers": [{"url": "https://example.com/api/v1"}], "paths": {`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `"/resource%d/items/{id}/sub%d": {"parameters": [{"$ref": "#/components/parameters/id"}],`, i/10, i)
+		b.WriteString(`"get": {"summary": "get", "responses": {"200": {"description": "ok"}}},`)
+		b.WriteString(`"post": {"summary": "post", "requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Item"}}}}}}`)
+	}
+	b.WriteString(`}, "components": {`)
+	b.WriteString(`"parameters": {"id": {"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}},`)
+	b.WriteString(`"schemas": {"Item": {"type": "object", "properties": {"name": {"type": "string"}}}}}}`)
+
+	return []byte(b.String())
+}
+
+func BenchmarkParse(b *testing.B) {
+	spec := largeSpec(1500)
+	for b.Loop() {
+		if _, err := openapi.Parse("json", spec); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetOp(b *testing.B) {
+	oai, err := openapi.Parse("json", largeSpec(1500))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for b.Loop() {
+		op := oai.GetOp("get", "{{url}}/api/v1/resource149/items/{{id}}/sub1499?x=1")
+		if op.Path != "/resource149/items/{id}/sub1499" {
+			b.Fatalf("matched %s", op.Path)
+		}
+	}
+}
+
+func BenchmarkPathList(b *testing.B) {
+	oai, err := openapi.Parse("json", largeSpec(1500))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for b.Loop() {
+		if len(oai.PathList()) != 1500 {
+			b.Fatal("missing paths")
+		}
+	}
+}
+
+func TestSwagger2(t *testing.T) {
+	contents, err := os.ReadFile("../fixtures/petstore_swagger.yaml")
+	expect.NoErr(t, err)
+	oai, err := openapi.Parse("yaml", contents)
+	expect.NoErr(t, err)
+
+	expect.Equals(t, []string{"/pets", "/pets/{petId}"}, oai.PathList())
+	expect.Equals(t, []openapi.Server{{URL: "https://petstore.swagger.io/v1"}}, oai.Servers)
+
+	t.Run("query parameters", func(t *testing.T) {
+		op := oai.GetOp("get", "{{url}}/v1/pets?limit=1")
+		expect.Equals(t, "/pets", op.Path)
+		expect.Equals(t, openapi.OpParams{{
+			Name:        "limit",
+			In:          "query",
+			Description: "How many items to return at one time",
+			Schema:      openapi.Schema{Type: "integer", Format: "int32"},
+		}}, op.Detail.Parameters)
+		expect.Equals(t, "A list of pets", op.Detail.Responses["200"].Description)
+	})
+
+	t.Run("body parameter", func(t *testing.T) {
+		op := oai.GetOp("post", "https://petstore.swagger.io/v1/pets")
+		expect.Equals(t, 0, len(op.Detail.Parameters))
+		expect.Equals(t, "The pet to create", op.Detail.RequestBody.Description)
+		expect.Equals(t, true, op.Detail.RequestBody.Required)
+		schema := op.Detail.RequestBody.JSONSchema()
+		expect.Equals(t, []string{"id", "name"}, schema.Required)
+		expect.Equals(t, "integer", schema.Properties["id"].Type)
+		expect.Equals(t, map[string]openapi.OpResponse{"201": {Description: "Created"}}, op.Detail.Responses)
+	})
+
+	t.Run("path parameters of the path item", func(t *testing.T) {
+		op := oai.GetOp("get", "/v1/pets/1")
+		expect.Equals(t, openapi.OpParams{{
+			Name:     "petId",
+			In:       "path",
+			Required: true,
+			Schema:   openapi.Schema{Type: "integer", Format: "int64"},
+		}}, op.Detail.Parameters)
+	})
+
+	t.Run("form parameters", func(t *testing.T) {
+		op := oai.GetOp("put", "/pets/1")
+		expect.Equals(t, (*openapi.Schema)(nil), op.Detail.RequestBody.JSONSchema())
+		form := op.Detail.RequestBody.Content["application/x-www-form-urlencoded"].Schema
+		expect.Equals(t, []string{"name"}, form.Required)
+		expect.Equals(t, "string", form.Properties["name"].Type)
+		expect.Equals(t, "integer", form.Properties["age"].Type)
+	})
+}
+
+func TestSpecs(t *testing.T) {
+	parse := func(paths ...string) openapi.OAI {
+		items := make([]string, 0, len(paths))
+		for _, p := range paths {
+			items = append(items, fmt.Sprintf("%q: {\"get\": {\"summary\": %q}}", p, p))
+		}
+
+		oai, err := openapi.Parse("json", []byte(`{"paths": {`+strings.Join(items, ",")+`}}`))
+		expect.NoErr(t, err)
+
+		return oai
+	}
+
+	specs := openapi.Specs{
+		openapi.NewSpec(parse("/users/{id}"), "https://users.example.com/v1", ""),
+		openapi.NewSpec(parse("/users/{id}/orders"), "{{orders_url}}", ""),
+		openapi.NewSpec(parse("/teams"), "{{teams_url}}/v2", ""),
+		openapi.NewSpec(parse("/pets"), "", "/repo/pets/**/*.hurl"),
+		openapi.NewSpec(parse("/store"), "", ""),
+	}
+
+	tests := []struct {
+		file, target, path string
+	}{
+		{file: "/repo/users.hurl", target: "https://users.example.com/v1/users/1", path: "/users/{id}"},
+		{file: "/repo/users.hurl", target: "{{orders_url}}/users/1/orders", path: "/users/{id}/orders"},
+		{file: "/repo/users.hurl", target: "{{teams_url}}/v2/teams", path: "/teams"},
+		{file: "/repo/users.hurl", target: "{{teams_url}}/teams"},
+		{file: "/repo/pets/get.hurl", target: "{{url}}/pets", path: "/pets"},
+		{file: "/repo/pets/nested/dir/get.hurl", target: "{{url}}/pets", path: "/pets"},
+		{file: "/repo/pets/get.hurl", target: "{{orders_url}}/users/1/orders", path: "/users/{id}/orders"},
+		{file: "/repo/store.hurl", target: "{{url}}/store", path: "/store"},
+		{file: "/repo/store.hurl", target: "{{url}}/pets"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file+" "+tt.target, func(t *testing.T) {
+			path, ok := specs.ForFile(tt.file).Find(tt.target).Match(tt.target)
+			expect.Equals(t, tt.path != "", ok)
+			expect.Equals(t, tt.path, path)
+		})
+	}
+
+	t.Run("template base url", func(t *testing.T) {
+		target := "{{orders_url}}/users/1/orders"
+		base, path, _ := specs.ForFile("/repo/users.hurl").Find(target).TargetPath(target)
+		expect.Equals(t, "{{orders_url}}", base)
+		expect.Equals(t, "/users/1/orders", path)
+	})
+
+	t.Run("no specs", func(t *testing.T) {
+		oai := openapi.Specs{}.ForFile("/repo/store.hurl").Find("/store")
+		expect.Equals(t, 0, len(oai.PathList()))
+	})
+}
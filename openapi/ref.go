@@ -0,0 +1,233 @@
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrRefCycle is returned when a chain of $refs leads back to itself
+var ErrRefCycle = errors.New("cyclic $ref")
+
+// Deref decodes the value that ref points to into v, following any $refs it
+// leads to. Only references within the document are supported, e.g.
+// #/components/schemas/Pet.
+func (o OAI) Deref(ref string, v any) error {
+	raw, err := o.follow(ref)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(raw, v)
+}
+
+// follow returns the first value in the chain of $refs starting at ref that
+// isn't a reference itself
+func (o OAI) follow(ref string) (json.RawMessage, error) {
+	seen := make(map[string]bool)
+	for {
+		if seen[ref] {
+			return nil, fmt.Errorf("%w %s", ErrRefCycle, ref)
+		}
+		seen[ref] = true
+
+		raw, err := o.pointer(ref)
+		if err != nil {
+			return nil, err
+		}
+
+		var next struct {
+			Ref string `json:"$ref"`
+		}
+		if json.Unmarshal(raw, &next) != nil || next.Ref == "" {
+			return raw, nil
+		}
+		ref = next.Ref
+	}
+}
+
+var pointerEscapes = strings.NewReplacer("~1", "/", "~0", "~")
+
+// pointer returns the value at the JSON pointer in the fragment of ref
+func (o OAI) pointer(ref string) (json.RawMessage, error) {
+	fragment, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return nil, fmt.Errorf("could not resolve %s, only references within the document are supported", ref)
+	}
+
+	fragment, err := url.PathUnescape(fragment)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve %s %w", ref, err)
+	}
+
+	raw := o.raw
+	if fragment == "" {
+		return raw, nil
+	}
+
+	notFound := fmt.Errorf("could not resolve %s, it isn't in the document", ref)
+	refs := o.refs
+	if refs == nil {
+		refs = newRefCache()
+	}
+
+	prefix := ""
+	for _, token := range strings.Split(strings.TrimPrefix(fragment, "/"), "/") {
+		container := prefix
+		prefix += "/" + token
+		token = pointerEscapes.Replace(token)
+
+		if obj, ok := refs.object(container, raw); ok {
+			if raw, ok = obj[token]; !ok {
+				return nil, notFound
+			}
+			continue
+		}
+
+		arr, ok := refs.array(container, raw)
+		if !ok {
+			return nil, notFound
+		}
+
+		i, err := strconv.Atoi(token)
+		if err != nil || i < 0 || i >= len(arr) {
+			return nil, notFound
+		}
+		raw = arr[i]
+	}
+
+	return raw, nil
+}
+
+// refCache keeps the objects and arrays decoded while resolving pointers, so
+// each part of the document is decoded once however often it's referenced.
+// The containers are keyed by their pointer.
+type refCache struct {
+	mu      sync.Mutex
+	objects map[string]map[string]json.RawMessage
+	arrays  map[string][]json.RawMessage
+}
+
+func newRefCache() *refCache {
+	return &refCache{
+		objects: make(map[string]map[string]json.RawMessage),
+		arrays:  make(map[string][]json.RawMessage),
+	}
+}
+
+func (c *refCache) object(pointer string, raw json.RawMessage) (map[string]json.RawMessage, bool) {
+	return cached(&c.mu, c.objects, pointer, raw)
+}
+
+func (c *refCache) array(pointer string, raw json.RawMessage) ([]json.RawMessage, bool) {
+	return cached(&c.mu, c.arrays, pointer, raw)
+}
+
+// cached decodes raw, or returns what was decoded for the pointer before
+func cached[T any](mu *sync.Mutex, m map[string]T, pointer string, raw json.RawMessage) (T, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	if v, ok := m[pointer]; ok {
+		return v, true
+	}
+
+	var v T
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return v, false
+	}
+	m[pointer] = v
+
+	return v, true
+}
+
+// resolve returns what ref points to, or v when ref is empty
+func resolve[T any](o OAI, ref string, v *T) (*T, error) {
+	if ref == "" {
+		return v, nil
+	}
+
+	var resolved T
+	if err := o.Deref(ref, &resolved); err != nil {
+		return nil, err
+	}
+
+	return &resolved, nil
+}
+
+// pathContent decodes a path item by method, following the item's $ref
+func (o OAI) pathContent(raw json.RawMessage) (map[string]json.RawMessage, error) {
+	item := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return nil, err
+	}
+
+	ref, ok := item["$ref"]
+	if !ok {
+		return item, nil
+	}
+
+	var target string
+	if err := json.Unmarshal(ref, &target); err != nil {
+		return nil, err
+	}
+
+	resolved := make(map[string]json.RawMessage)
+	if err := o.Deref(target, &resolved); err != nil {
+		return nil, err
+	}
+
+	return resolved, nil
+}
+
+// resolveDetail replaces the references of an operation with what they point
+// to. Parameters of the path are added unless the operation overrides them.
+// References that can't be resolved, like cycles, are left out.
+func (o OAI) resolveDetail(detail OpDetail, shared OpParams) OpDetail {
+	params := make(OpParams, 0, len(detail.Parameters)+len(shared))
+	seen := make(map[string]bool)
+	for _, param := range append(slices.Clone(detail.Parameters), shared...) {
+		resolved, err := resolve(o, param.Ref, &param)
+		if err != nil {
+			continue
+		}
+
+		key := resolved.In + " " + resolved.Name
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if schema := o.Resolve(&resolved.Schema); schema != nil {
+			resolved.Schema = *schema
+		}
+		params = append(params, *resolved)
+	}
+	detail.Parameters = params
+
+	if detail.RequestBody != nil {
+		rb, err := resolve(o, detail.RequestBody.Ref, detail.RequestBody)
+		if err == nil {
+			for name, mt := range rb.Content {
+				mt.Schema = o.Resolve(mt.Schema)
+				rb.Content[name] = mt
+			}
+		}
+		detail.RequestBody = rb
+	}
+
+	for code, resp := range detail.Responses {
+		resolved, err := resolve(o, resp.Ref, &resp)
+		if err != nil {
+			delete(detail.Responses, code)
+			continue
+		}
+		detail.Responses[code] = *resolved
+	}
+
+	return detail
+}
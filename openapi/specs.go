@@ -0,0 +1,120 @@
+package openapi
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Finder finds the spec that documents a request target
+type Finder interface {
+	Find(target string) OAI
+}
+
+// Find returns the spec itself, so a single spec documents every target
+func (o OAI) Find(target string) OAI {
+	return o
+}
+
+// Spec is a spec and the requests it documents. A spec with neither a base
+// url nor a glob documents every request no other spec does.
+type Spec struct {
+	OAI OAI
+	// BaseURL is the start of the targets of the requests the spec documents,
+	// a template like {{users_url}} or a url like https://users.example.com/v1
+	BaseURL string
+	// Files is a glob of the hurl files the spec documents, ** matches any
+	// number of directories
+	Files string
+}
+
+type Specs []Spec
+
+// NewSpec returns a spec for the requests starting with baseURL or in the
+// files matching the glob. The path of the base url is removed from targets
+// like the path of a server, so /v1 in https://users.example.com/v1 or
+// {{users_url}}/v1 doesn't have to be in the paths of the spec.
+func NewSpec(oai OAI, baseURL, files string) Spec {
+	// only what comes after the host or the {{template}} is a path, the
+	// template itself is matched as text by Find
+	if _, path, _ := SplitTarget(baseURL); path != "" {
+		if re := (Server{URL: path}).pathRegexp(); re != nil {
+			oai.serverPaths = append(slices.Clip(oai.serverPaths), re)
+		}
+	}
+
+	return Spec{OAI: oai, BaseURL: baseURL, Files: files}
+}
+
+// ForFile returns the specs that can document the requests of a hurl file
+func (s Specs) ForFile(file string) Finder {
+	specs := make(Specs, 0, len(s))
+	for _, spec := range s {
+		if spec.Files == "" || matchGlob(spec.Files, file) {
+			specs = append(specs, spec)
+		}
+	}
+
+	return fileSpecs(specs)
+}
+
+// fileSpecs are the specs of a single hurl file
+type fileSpecs Specs
+
+// Find returns the spec with the longest base url the target starts with, or
+// else the first spec of the file's glob or the first spec without either.
+// It returns an empty spec when no spec documents the target.
+func (s fileSpecs) Find(target string) OAI {
+	var byBase, byFiles, fallback *Spec
+	for i, spec := range s {
+		switch {
+		case spec.BaseURL != "":
+			if strings.HasPrefix(target, spec.BaseURL) && (byBase == nil || len(spec.BaseURL) > len(byBase.BaseURL)) {
+				byBase = &s[i]
+			}
+		case spec.Files != "":
+			if byFiles == nil {
+				byFiles = &s[i]
+			}
+		default:
+			if fallback == nil {
+				fallback = &s[i]
+			}
+		}
+	}
+
+	for _, spec := range []*Spec{byBase, byFiles, fallback} {
+		if spec != nil {
+			return spec.OAI
+		}
+	}
+
+	return OAI{}
+}
+
+// matchGlob reports whether the glob matches the whole path. * and ? don't
+// match a /, and **/ matches any number of directories.
+func matchGlob(glob, path string) bool {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += len("**/") - 1
+		case strings.HasPrefix(glob[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			expr.WriteString("[^/]*")
+		case glob[i] == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	expr.WriteString("$")
+
+	ok, err := regexp.MatchString(expr.String(), path)
+	return err == nil && ok
+}
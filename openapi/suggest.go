@@ -0,0 +1,103 @@
+package openapi
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// reTargetBase matches what comes before the path of a request target, a
+// {{template}} like {{base_url}} or a scheme and host
+var reTargetBase = regexp.MustCompile(`^(?:\{\{[^}]*\}\}|[a-zA-Z][a-zA-Z0-9+.-]*://[^/?#]*)+`)
+
+// SplitTarget splits a request target into its base URL, its path and the
+// query string or fragment at the end, e.g. {{url}}/pets?limit=1 is split
+// into {{url}}, /pets and ?limit=1.
+func SplitTarget(target string) (base, path, query string) {
+	base = reTargetBase.FindString(target)
+	path = target[len(base):]
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path, query = path[:i], path[i:]
+	}
+
+	return base, path, query
+}
+
+// ClosestPaths returns up to n paths in the spec ordered by how few edits
+// turn the path of the target into them.
+func (o OAI) ClosestPaths(target string, n int) []string {
+	_, path, _ := o.TargetPath(target)
+	paths := o.PathList()
+	distances := make(map[string]int, len(paths))
+	for _, p := range paths {
+		distances[p] = distance(path, p)
+	}
+
+	slices.SortFunc(paths, func(a, b string) int {
+		if distances[a] != distances[b] {
+			return distances[a] - distances[b]
+		}
+
+		return strings.Compare(a, b)
+	})
+
+	return paths[:min(n, len(paths))]
+}
+
+// FillPath returns the path in the spec with its parameters replaced by the
+// segments of the request path at the same place, e.g. /pet/{petId}/upload
+// is filled to /pet/42/upload for /pet/42/uplod. ok is false when the request
+// path has no segment for a parameter.
+func FillPath(path, pathInSpec string) (string, bool) {
+	parts := splitPath(path)
+	segments := parseSegments(pathInSpec)
+	filled := make([]string, 0, len(segments))
+	for i, seg := range segments {
+		if seg.param == nil {
+			filled = append(filled, seg.literal)
+			continue
+		}
+
+		if i >= len(parts) {
+			return "", false
+		}
+		filled = append(filled, parts[i])
+	}
+
+	return "/" + strings.Join(filled, "/"), true
+}
+
+// ClosestMethods returns the documented methods of the path in the spec, the
+// ones closest to method first
+func (o OAI) ClosestMethods(method, pathInSpec string) []string {
+	methods := o.Methods(pathInSpec)
+	method = strings.ToLower(method)
+	slices.SortStableFunc(methods, func(a, b string) int {
+		return distance(method, a) - distance(method, b)
+	})
+
+	return methods
+}
+
+// distance is the Levenshtein distance between a and b
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,342 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// isSwagger2 reports whether the document is a Swagger 2.0 spec rather than
+// an OpenAPI 3.x one
+func isSwagger2(doc []byte) bool {
+	var version struct {
+		Swagger string `json:"swagger"`
+	}
+
+	return json.Unmarshal(doc, &version) == nil && strings.HasPrefix(version.Swagger, "2.")
+}
+
+// swaggerRefs are the places Swagger 2.0 keeps reusable objects and where
+// OpenAPI 3.x keeps them
+var swaggerRefs = map[string]string{
+	"#/definitions/": "#/components/schemas/",
+	"#/parameters/":  "#/components/parameters/",
+	"#/responses/":   "#/components/responses/",
+}
+
+// fromSwagger2 converts a Swagger 2.0 document to OpenAPI 3.x, so the rest of
+// the package only knows one model:
+//   - host, basePath and schemes become servers
+//   - definitions, parameters and responses move to the components
+//   - body and formData parameters become the operation's requestBody with
+//     a content for each type in consumes
+//   - the schema of a response gets a content for each type in produces
+//   - other parameters keep their type in a schema
+func fromSwagger2(doc []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	var spec map[string]any
+	if err := dec.Decode(&spec); err != nil {
+		return nil, err
+	}
+	rewriteRefs(spec)
+
+	s := swagger{
+		params:   object(spec["parameters"]),
+		consumes: stringList(spec["consumes"], []string{"application/json"}),
+		produces: stringList(spec["produces"], []string{"application/json"}),
+	}
+
+	out := map[string]any{
+		"openapi": "3.0.0",
+		"info":    spec["info"],
+		"servers": s.servers(spec),
+	}
+
+	paths := make(map[string]any)
+	for path, item := range object(spec["paths"]) {
+		paths[path] = s.pathItem(object(item))
+	}
+	out["paths"] = paths
+
+	components := make(map[string]any)
+	if definitions, ok := spec["definitions"]; ok {
+		components["schemas"] = definitions
+	}
+
+	params := make(map[string]any)
+	for name, param := range s.params {
+		if in := object(param)["in"]; in != "body" && in != "formData" {
+			params[name] = s.parameter(object(param))
+		}
+	}
+	components["parameters"] = params
+
+	responses := make(map[string]any)
+	for name, resp := range object(spec["responses"]) {
+		responses[name] = s.response(object(resp), s.produces)
+	}
+	components["responses"] = responses
+	out["components"] = components
+
+	return json.Marshal(out)
+}
+
+type swagger struct {
+	// params are the parameters shared by the whole document
+	params             map[string]any
+	consumes, produces []string
+}
+
+func (s swagger) servers(spec map[string]any) []any {
+	basePath, _ := spec["basePath"].(string)
+	host, _ := spec["host"].(string)
+	if host == "" {
+		if basePath == "" {
+			return []any{}
+		}
+
+		return []any{map[string]any{"url": basePath}}
+	}
+
+	schemes := stringList(spec["schemes"], []string{"https"})
+	servers := make([]any, 0, len(schemes))
+	for _, scheme := range schemes {
+		servers = append(servers, map[string]any{"url": scheme + "://" + host + basePath})
+	}
+
+	return servers
+}
+
+func (s swagger) pathItem(item map[string]any) map[string]any {
+	out := make(map[string]any, len(item))
+	shared := asSlice(item["parameters"])
+	for key, value := range item {
+		switch key {
+		case "parameters":
+			out[key] = s.parameters(shared)
+		case "get", "put", "post", "delete", "options", "head", "patch":
+			out[key] = s.operation(object(value), shared)
+		default:
+			out[key] = value
+		}
+	}
+
+	return out
+}
+
+func (s swagger) operation(op map[string]any, shared []any) map[string]any {
+	out := make(map[string]any, len(op))
+	for key, value := range op {
+		switch key {
+		case "consumes", "produces":
+		case "parameters":
+			out[key] = s.parameters(asSlice(value))
+		case "responses":
+			responses := make(map[string]any)
+			for code, resp := range object(value) {
+				responses[code] = s.response(object(resp), stringList(op["produces"], s.produces))
+			}
+			out[key] = responses
+		default:
+			out[key] = value
+		}
+	}
+
+	params := slices.Concat(asSlice(op["parameters"]), shared)
+	if body := s.requestBody(params, stringList(op["consumes"], s.consumes)); body != nil {
+		out["requestBody"] = body
+	}
+
+	return out
+}
+
+// parameters converts the parameters that aren't part of the body
+func (s swagger) parameters(params []any) []any {
+	out := make([]any, 0, len(params))
+	for _, param := range params {
+		if in := s.deref(object(param))["in"]; in == "body" || in == "formData" {
+			continue
+		}
+
+		out = append(out, s.parameter(object(param)))
+	}
+
+	return out
+}
+
+// parameter moves the type of a parameter into its schema
+func (s swagger) parameter(param map[string]any) map[string]any {
+	if _, ok := param["$ref"]; ok {
+		return param
+	}
+
+	out := make(map[string]any, len(param))
+	schema := make(map[string]any)
+	for key, value := range param {
+		switch key {
+		case "type", "format", "items", "enum", "default", "minimum", "maximum", "pattern":
+			schema[key] = value
+		case "collectionFormat", "allowEmptyValue":
+		default:
+			out[key] = value
+		}
+	}
+
+	if len(schema) > 0 {
+		out["schema"] = schema
+	}
+
+	return out
+}
+
+// requestBody makes the body out of the body parameter, or the formData
+// parameters as the properties of an object. It is nil when there are neither.
+func (s swagger) requestBody(params []any, consumes []string) map[string]any {
+	var body map[string]any
+	form := map[string]any{"type": "object", "properties": map[string]any{}}
+	formRequired := make([]any, 0)
+	hasForm := false
+	for _, param := range params {
+		param := s.deref(object(param))
+		switch param["in"] {
+		case "body":
+			if body == nil {
+				body = param
+			}
+		case "formData":
+			hasForm = true
+			name, _ := param["name"].(string)
+			form["properties"].(map[string]any)[name] = s.parameter(param)["schema"]
+			if param["required"] == true {
+				formRequired = append(formRequired, name)
+			}
+		}
+	}
+
+	out := make(map[string]any)
+	var schema any
+	switch {
+	case body != nil:
+		schema = body["schema"]
+		if desc, ok := body["description"]; ok {
+			out["description"] = desc
+		}
+		if required, ok := body["required"]; ok {
+			out["required"] = required
+		}
+	case hasForm:
+		if len(formRequired) > 0 {
+			form["required"] = formRequired
+		}
+		schema = form
+		consumes = formTypes(consumes)
+	default:
+		return nil
+	}
+
+	content := make(map[string]any, len(consumes))
+	for _, mt := range consumes {
+		content[mt] = map[string]any{"schema": schema}
+	}
+	out["content"] = content
+
+	return out
+}
+
+// formTypes returns the form media types in consumes, formData parameters
+// are sent url encoded when there are none
+func formTypes(consumes []string) []string {
+	types := slices.DeleteFunc(slices.Clone(consumes), func(mt string) bool {
+		return mt != "application/x-www-form-urlencoded" && mt != "multipart/form-data"
+	})
+
+	if len(types) == 0 {
+		return []string{"application/x-www-form-urlencoded"}
+	}
+
+	return types
+}
+
+func (s swagger) response(resp map[string]any, produces []string) map[string]any {
+	out := make(map[string]any, len(resp))
+	for key, value := range resp {
+		if key != "schema" {
+			out[key] = value
+		}
+	}
+
+	if schema, ok := resp["schema"]; ok {
+		content := make(map[string]any, len(produces))
+		for _, mt := range produces {
+			content[mt] = map[string]any{"schema": schema}
+		}
+		out["content"] = content
+	}
+
+	return out
+}
+
+// deref returns the shared parameter a parameter refers to, or the parameter
+func (s swagger) deref(param map[string]any) map[string]any {
+	ref, ok := param["$ref"].(string)
+	if !ok {
+		return param
+	}
+
+	if shared, ok := s.params[strings.TrimPrefix(ref, "#/components/parameters/")].(map[string]any); ok {
+		return shared
+	}
+
+	return param
+}
+
+// rewriteRefs points the $refs of the document to where fromSwagger2 moves
+// what they refer to
+func rewriteRefs(v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if ref, ok := value.(string); ok && key == "$ref" {
+				for from, to := range swaggerRefs {
+					if strings.HasPrefix(ref, from) {
+						v[key] = to + strings.TrimPrefix(ref, from)
+					}
+				}
+				continue
+			}
+			rewriteRefs(value)
+		}
+	case []any:
+		for _, value := range v {
+			rewriteRefs(value)
+		}
+	}
+}
+
+func object(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
+
+func asSlice(v any) []any {
+	s, _ := v.([]any)
+	return s
+}
+
+// stringList returns the strings in v, or def when there are none
+func stringList(v any, def []string) []string {
+	types := make([]string, 0)
+	for _, t := range asSlice(v) {
+		if t, ok := t.(string); ok {
+			types = append(types, t)
+		}
+	}
+
+	if len(types) == 0 {
+		return def
+	}
+
+	return types
+}
@@ -0,0 +1,180 @@
+package semantictokens
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/ethancarlsson/hurl-lsp/document"
+	"github.com/ethancarlsson/hurl-lsp/hurlfile"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+const (
+	modDefaultLibrary = 1 << iota
+	modDeclaration
+)
+
+type tokenType struct {
+	index     protocol.UInteger
+	modifiers protocol.UInteger
+}
+
+var (
+	tokenTypes = []string{
+		"keyword", "string", "number", "property", "namespace",
+		"variable", "function", "operator", "regexp", "comment",
+	}
+	tokenModifiers = []string{"defaultLibrary", "declaration"}
+
+	kinds = map[hurlfile.TokenKind]tokenType{
+		hurlfile.TokenMethod:      {0, 0},
+		hurlfile.TokenURL:         {1, 0},
+		hurlfile.TokenVersion:     {0, 0},
+		hurlfile.TokenStatus:      {2, 0},
+		hurlfile.TokenKey:         {3, 0},
+		hurlfile.TokenValue:       {1, 0},
+		hurlfile.TokenSectionName: {4, 0},
+		hurlfile.TokenVariable:    {5, modDeclaration},
+		hurlfile.TokenQuery:       {6, modDefaultLibrary},
+		hurlfile.TokenFilter:      {6, modDefaultLibrary},
+		hurlfile.TokenPredicate:   {7, modDefaultLibrary},
+		hurlfile.TokenString:      {1, 0},
+		hurlfile.TokenRegex:       {8, 0},
+		hurlfile.TokenNumber:      {2, 0},
+		hurlfile.TokenKeyword:     {0, 0},
+		hurlfile.TokenTemplate:    {5, 0},
+		hurlfile.TokenComment:     {9, 0},
+		hurlfile.TokenBody:        {1, 0},
+	}
+)
+
+// Legend is the legend the server advertises, the token types and modifiers
+// in the encoded data are indexes into it.
+var Legend = protocol.SemanticTokensLegend{
+	TokenTypes:     tokenTypes,
+	TokenModifiers: tokenModifiers,
+}
+
+// Encode encodes the tokens with relative positions as the LSP expects. Only
+// tokens within rng are included if it isn't nil.
+func Encode(tokens []hurlfile.Token, lines []string, rng *protocol.Range) []protocol.UInteger {
+	data := make([]protocol.UInteger, 0, len(tokens)*5)
+	prevLine, prevStart := 0, 0
+	for _, t := range tokens {
+		line := t.Range.StartLine
+		if line >= len(lines) {
+			continue
+		}
+		if rng != nil && (line < int(rng.Start.Line) || line > int(rng.End.Line)) {
+			continue
+		}
+
+		tt, ok := kinds[t.Kind]
+		if !ok {
+			continue
+		}
+
+		start := int(document.Position(lines, line, t.Range.StartCol).Character)
+		length := int(document.Position(lines, line, t.Range.EndCol).Character) - start
+
+		deltaStart := start
+		if line == prevLine {
+			deltaStart = start - prevStart
+		}
+
+		data = append(data,
+			protocol.UInteger(line-prevLine),
+			protocol.UInteger(deltaStart),
+			protocol.UInteger(length),
+			tt.index,
+			tt.modifiers,
+		)
+		prevLine, prevStart = line, start
+	}
+
+	return data
+}
+
+type result struct {
+	id   string
+	data []protocol.UInteger
+}
+
+// Cache keeps the last tokens sent for each document so that only the
+// changes need to be sent for big files.
+type Cache struct {
+	mu      sync.Mutex
+	nextID  int
+	results map[string]result
+}
+
+func NewCache() *Cache {
+	return &Cache{results: map[string]result{}}
+}
+
+// Full stores the tokens of the document and returns them all
+func (c *Cache) Full(uri string, data []protocol.UInteger) *protocol.SemanticTokens {
+	id := c.store(uri, data)
+
+	return &protocol.SemanticTokens{ResultID: &id, Data: data}
+}
+
+// Delta returns the edits from the previously sent tokens to data, or all the
+// tokens if the previous result is no longer known.
+func (c *Cache) Delta(uri, previousID string, data []protocol.UInteger) any {
+	c.mu.Lock()
+	prev, ok := c.results[uri]
+	c.mu.Unlock()
+
+	if !ok || prev.id != previousID {
+		return c.Full(uri, data)
+	}
+
+	id := c.store(uri, data)
+
+	return &protocol.SemanticTokensDelta{ResultId: &id, Edits: diff(prev.data, data)}
+}
+
+// Evict forgets the tokens of a closed document
+func (c *Cache) Evict(uri string) {
+	c.mu.Lock()
+	delete(c.results, uri)
+	c.mu.Unlock()
+}
+
+func (c *Cache) store(uri string, data []protocol.UInteger) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nextID++
+	id := strconv.Itoa(c.nextID)
+	c.results[uri] = result{id: id, data: data}
+
+	return id
+}
+
+// diff returns a single edit replacing everything between the common prefix
+// and suffix of the old and new data. Editing usually changes one place at a
+// time so this is almost always as small as a full diff.
+func diff(old, new []protocol.UInteger) []protocol.SemanticTokensEdit {
+	prefix := 0
+	for prefix < len(old) && prefix < len(new) && old[prefix] == new[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(new)-prefix &&
+		old[len(old)-1-suffix] == new[len(new)-1-suffix] {
+		suffix++
+	}
+
+	if prefix == len(old) && prefix == len(new) {
+		return []protocol.SemanticTokensEdit{}
+	}
+
+	return []protocol.SemanticTokensEdit{{
+		Start:       protocol.UInteger(prefix),
+		DeleteCount: protocol.UInteger(len(old) - prefix - suffix),
+		Data:        new[prefix : len(new)-suffix],
+	}}
+}
@@ -0,0 +1,59 @@
+package semantictokens_test
+
+import (
+	"testing"
+
+	"github.com/ethancarlsson/hurl-lsp/expect"
+	"github.com/ethancarlsson/hurl-lsp/hurlfile"
+	"github.com/ethancarlsson/hurl-lsp/semantictokens"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestEncode(t *testing.T) {
+	lines := []string{"GET {{url}}/ü", "HTTP 200", "# 😀 done"}
+	hf, err := hurlfile.Parse(lines)
+	expect.NoErr(t, err)
+
+	expect.Equals(t, []protocol.UInteger{
+		0, 0, 3, 0, 0, // GET
+		0, 4, 7, 5, 0, // {{url}}
+		0, 7, 2, 1, 0, // /ü
+		1, 0, 4, 0, 0, // HTTP
+		0, 5, 3, 2, 0, // 200
+		1, 0, 9, 9, 0, // # 😀 done, the emoji is 2 utf-16 code units
+	}, semantictokens.Encode(hf.Tokens, lines, nil))
+
+	expect.Equals(t, []protocol.UInteger{
+		1, 0, 4, 0, 0,
+		0, 5, 3, 2, 0,
+	}, semantictokens.Encode(hf.Tokens, lines, &protocol.Range{
+		Start: protocol.Position{Line: 1},
+		End:   protocol.Position{Line: 1, Character: 8},
+	}))
+}
+
+func TestCache(t *testing.T) {
+	c := semantictokens.NewCache()
+	uri := "file:///test.hurl"
+
+	full := c.Full(uri, []protocol.UInteger{0, 0, 3, 0, 0, 0, 4, 7, 5, 0})
+
+	delta := c.Delta(uri, *full.ResultID, []protocol.UInteger{0, 0, 4, 0, 0, 0, 5, 7, 5, 0})
+	d, ok := delta.(*protocol.SemanticTokensDelta)
+	expect.Equals(t, true, ok)
+	expect.Equals(t, []protocol.SemanticTokensEdit{
+		{Start: 2, DeleteCount: 5, Data: []protocol.UInteger{4, 0, 0, 0, 5}},
+	}, d.Edits)
+
+	// unchanged
+	delta = c.Delta(uri, *d.ResultId, []protocol.UInteger{0, 0, 4, 0, 0, 0, 5, 7, 5, 0})
+	expect.Equals(t, []protocol.SemanticTokensEdit{}, delta.(*protocol.SemanticTokensDelta).Edits)
+
+	// unknown previous result
+	_, ok = c.Delta(uri, "old", []protocol.UInteger{}).(*protocol.SemanticTokens)
+	expect.Equals(t, true, ok)
+
+	c.Evict(uri)
+	_, ok = c.Delta(uri, *d.ResultId, []protocol.UInteger{}).(*protocol.SemanticTokens)
+	expect.Equals(t, true, ok)
+}
@@ -12,7 +12,7 @@ var reWhitespace = regexp.MustCompile("\\s")
 type Lines []string
 
 func (l Lines) SymbolAt(lineNum, col int) Signature {
-	if lineNum > len(l) {
+	if lineNum >= len(l) {
 		return ""
 	}
 
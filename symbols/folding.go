@@ -0,0 +1,72 @@
+package symbols
+
+import (
+	"strings"
+
+	"github.com/ethancarlsson/hurl-lsp/hurlfile"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Folding returns the folding ranges of every entry, section, multi-line body
+// and run of comments.
+func Folding(hf *hurlfile.HurlFile, lines []string) []protocol.FoldingRange {
+	folds := make([]protocol.FoldingRange, 0)
+	add := func(start, end int, kind *string) {
+		r := linesRange(lines, start, end)
+		if r.End.Line <= r.Start.Line {
+			return
+		}
+
+		folds = append(folds, protocol.FoldingRange{StartLine: r.Start.Line, EndLine: r.End.Line, Kind: kind})
+	}
+
+	region := string(protocol.FoldingRangeKindRegion)
+	for _, entry := range hf.Entries {
+		add(entry.Range.StartLine, entry.Range.EndLine, &region)
+
+		req := entry.Request
+		for _, sec := range req.Sections {
+			add(sec.Range.StartLine, sec.Range.EndLine, &region)
+		}
+
+		if len(req.Body.Value) > 0 {
+			add(req.Body.Range.StartLine, req.Body.Range.EndLine, &region)
+		}
+
+		if resp := entry.Response; resp != nil {
+			for _, sec := range resp.Sections {
+				add(sec.Range.StartLine, sec.Range.EndLine, &region)
+			}
+
+			if resp.Body.Value != "" {
+				add(resp.Body.Range.StartLine, resp.Body.Range.EndLine, &region)
+			}
+		}
+	}
+
+	comment := string(protocol.FoldingRangeKindComment)
+	for i := 0; i < len(lines); i++ {
+		if !isComment(lines[i]) {
+			continue
+		}
+
+		start := i
+		for i+1 < len(lines) && isComment(lines[i+1]) {
+			i++
+		}
+
+		if i > start {
+			folds = append(folds, protocol.FoldingRange{
+				StartLine: protocol.UInteger(start),
+				EndLine:   protocol.UInteger(i),
+				Kind:      &comment,
+			})
+		}
+	}
+
+	return folds
+}
+
+func isComment(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "#")
+}
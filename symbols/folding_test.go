@@ -0,0 +1,47 @@
+package symbols_test
+
+import (
+	"testing"
+
+	"github.com/ethancarlsson/hurl-lsp/expect"
+	"github.com/ethancarlsson/hurl-lsp/hurlfile"
+	"github.com/ethancarlsson/hurl-lsp/symbols"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestFolding(t *testing.T) {
+	lines := []string{
+		"# Create a pet", // 0
+		"# then fetch it",
+		"POST {{url}}/pets", // 2
+		"[Options]",
+		"insecure: true",
+		"{", // 5
+		`  "name": "rex"`,
+		"}",
+		"HTTP 201", // 8
+		"[Captures]",
+		"id: jsonpath \"$.id\"",
+		"",
+		"GET {{url}}/pets/{{id}}", // 12
+		"HTTP 200",
+		"```",
+		"rex",
+		"```", // 16
+		"# single comment",
+	}
+	hf, err := hurlfile.Parse(lines)
+	expect.NoErr(t, err)
+
+	region := string(protocol.FoldingRangeKindRegion)
+	comment := string(protocol.FoldingRangeKindComment)
+	expect.Equals(t, []protocol.FoldingRange{
+		{StartLine: 2, EndLine: 10, Kind: &region},
+		{StartLine: 3, EndLine: 4, Kind: &region},
+		{StartLine: 5, EndLine: 7, Kind: &region},
+		{StartLine: 9, EndLine: 10, Kind: &region},
+		{StartLine: 12, EndLine: 16, Kind: &region},
+		{StartLine: 14, EndLine: 16, Kind: &region},
+		{StartLine: 0, EndLine: 1, Kind: &comment},
+	}, symbols.Folding(hf, lines))
+}
@@ -0,0 +1,88 @@
+package symbols
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethancarlsson/hurl-lsp/document"
+	"github.com/ethancarlsson/hurl-lsp/hurlfile"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Document returns the outline of the hurl file, an entry per request with
+// its headers, sections and response as children.
+func Document(hf *hurlfile.HurlFile, lines []string) []protocol.DocumentSymbol {
+	syms := make([]protocol.DocumentSymbol, 0, len(hf.Entries))
+	for _, entry := range hf.Entries {
+		req := entry.Request
+		name := strings.TrimSpace(req.Method.Name + " " + req.Target.Target)
+		sym := protocol.DocumentSymbol{
+			Name:           name,
+			Kind:           protocol.SymbolKindMethod,
+			Range:          linesRange(lines, entry.Range.StartLine, entry.Range.EndLine),
+			SelectionRange: linesRange(lines, req.Range.StartLine, req.Range.StartLine),
+			Children:       []protocol.DocumentSymbol{},
+		}
+
+		if len(req.Headers.Value) > 0 {
+			sym.Children = append(sym.Children, protocol.DocumentSymbol{
+				Name:           "Headers",
+				Detail:         ptr(fmt.Sprintf("%d headers", len(req.Headers.Value))),
+				Kind:           protocol.SymbolKindProperty,
+				Range:          linesRange(lines, req.Headers.Range.StartLine, req.Headers.Range.EndLine),
+				SelectionRange: linesRange(lines, req.Headers.Range.StartLine, req.Headers.Range.StartLine),
+			})
+		}
+
+		sym.Children = append(sym.Children, sections(lines, req.Sections)...)
+
+		if resp := entry.Response; resp != nil {
+			sym.Children = append(sym.Children, protocol.DocumentSymbol{
+				Name:           strings.TrimSpace(lines[resp.Range.StartLine]),
+				Kind:           protocol.SymbolKindEvent,
+				Range:          linesRange(lines, resp.Range.StartLine, resp.Range.StartLine),
+				SelectionRange: linesRange(lines, resp.Range.StartLine, resp.Range.StartLine),
+			})
+			sym.Children = append(sym.Children, sections(lines, resp.Sections)...)
+		}
+
+		syms = append(syms, sym)
+	}
+
+	return syms
+}
+
+func sections(lines []string, secs []hurlfile.Section) []protocol.DocumentSymbol {
+	syms := make([]protocol.DocumentSymbol, 0, len(secs))
+	for _, sec := range secs {
+		syms = append(syms, protocol.DocumentSymbol{
+			Name:           "[" + sec.Name.Value + "]",
+			Kind:           protocol.SymbolKindNamespace,
+			Range:          linesRange(lines, sec.Range.StartLine, sec.Range.EndLine),
+			SelectionRange: linesRange(lines, sec.Range.StartLine, sec.Range.StartLine),
+		})
+	}
+
+	return syms
+}
+
+// linesRange is the range of whole lines from start to end, ignoring the
+// blank lines and comments at the end that separate it from what follows.
+func linesRange(lines []string, start, end int) protocol.Range {
+	end = min(end, len(lines)-1)
+	for end > start {
+		if strings.TrimSpace(lines[end]) != "" && !isComment(lines[end]) {
+			break
+		}
+		end--
+	}
+
+	return protocol.Range{
+		Start: protocol.Position{Line: protocol.UInteger(start), Character: 0},
+		End:   document.Position(lines, end, len(lines[end])),
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
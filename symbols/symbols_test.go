@@ -0,0 +1,59 @@
+package symbols_test
+
+import (
+	"testing"
+
+	"github.com/ethancarlsson/hurl-lsp/expect"
+	"github.com/ethancarlsson/hurl-lsp/hurlfile"
+	"github.com/ethancarlsson/hurl-lsp/symbols"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestDocument(t *testing.T) {
+	lines := []string{
+		"POST {{url}}/pets", // 0
+		"Accept: */*",
+		"X-Id: 1",
+		"[Options]", // 3
+		"insecure: true",
+		"HTTP 201", // 5
+		"[Captures]",
+		"id: jsonpath \"$.id\"",
+		"",
+		"# next",
+		"PATCH", // 10
+		"",
+	}
+	hf, err := hurlfile.Parse(lines)
+	expect.NoErr(t, err)
+
+	rng := func(sl, sc, el, ec protocol.UInteger) protocol.Range {
+		return protocol.Range{
+			Start: protocol.Position{Line: sl, Character: sc},
+			End:   protocol.Position{Line: el, Character: ec},
+		}
+	}
+	detail := "2 headers"
+
+	expect.Equals(t, []protocol.DocumentSymbol{
+		{
+			Name:           "POST {{url}}/pets",
+			Kind:           protocol.SymbolKindMethod,
+			Range:          rng(0, 0, 7, 19),
+			SelectionRange: rng(0, 0, 0, 17),
+			Children: []protocol.DocumentSymbol{
+				{Name: "Headers", Detail: &detail, Kind: protocol.SymbolKindProperty, Range: rng(1, 0, 2, 7), SelectionRange: rng(1, 0, 1, 11)},
+				{Name: "[Options]", Kind: protocol.SymbolKindNamespace, Range: rng(3, 0, 4, 14), SelectionRange: rng(3, 0, 3, 9)},
+				{Name: "HTTP 201", Kind: protocol.SymbolKindEvent, Range: rng(5, 0, 5, 8), SelectionRange: rng(5, 0, 5, 8)},
+				{Name: "[Captures]", Kind: protocol.SymbolKindNamespace, Range: rng(6, 0, 7, 19), SelectionRange: rng(6, 0, 6, 10)},
+			},
+		},
+		{
+			Name:           "PATCH",
+			Kind:           protocol.SymbolKindMethod,
+			Range:          rng(10, 0, 10, 5),
+			SelectionRange: rng(10, 0, 10, 5),
+			Children:       []protocol.DocumentSymbol{},
+		},
+	}, symbols.Document(hf, lines))
+}